@@ -2,16 +2,44 @@ package client
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/configmap"
+	"github.com/alehechka/kube-external-sync/client/replicate/dynamic"
+	"github.com/alehechka/kube-external-sync/client/replicate/gateway/grpcroute"
+	"github.com/alehechka/kube-external-sync/client/replicate/gateway/httproute"
+	"github.com/alehechka/kube-external-sync/client/replicate/gateway/tlsroute"
 	"github.com/alehechka/kube-external-sync/client/replicate/ingress"
+	"github.com/alehechka/kube-external-sync/client/replicate/istio"
+	"github.com/alehechka/kube-external-sync/client/replicate/istio/destinationrule"
+	"github.com/alehechka/kube-external-sync/client/replicate/istio/virtualservice"
+	"github.com/alehechka/kube-external-sync/client/replicate/secret"
 	"github.com/alehechka/kube-external-sync/client/replicate/service"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
 	"github.com/alehechka/kube-external-sync/client/replicate/traefik/ingressroute"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik/ingressroutetcp"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik/ingressrouteudp"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik/middleware"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik/traefikservice"
+	log "github.com/sirupsen/logrus"
 	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/clientset/versioned"
+	traefikinformers "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/informers/externalversions"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicclient "k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	gatewayversioned "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions"
 )
 
 // SyncConfig contains the configuration options for the SyncExternals operation.
@@ -19,9 +47,34 @@ type SyncConfig struct {
 	PodNamespace string
 
 	LivenessPort           int
+	ShutdownTimeout        time.Duration
 	ResyncPeriod           time.Duration
+	Workers                int
 	DefaultIngressHostname string
+	HostTemplate           *template.Template
+	MaxHostLabelLength     int
 	EnableTraefik          bool
+	TraefikRuleSyntax      string
+	EnableGatewayAPI       bool
+	EnableGatewayAPIAlpha  bool
+	EnableIstio            bool
+	EnableTLSSecrets       bool
+	EnableConfigMaps       bool
+	DynamicResources       []dynamic.Resource
+	ServerSideApply        bool
+	ServiceMesh            string
+	ClusterDomain          string
+	CertificateIssuer      common.CertificateIssuer
+
+	OrphanCollectionInterval time.Duration
+	OrphanPolicy             common.OrphanPolicy
+
+	LeaderElection bool
+	LeaseName      string
+	LeaseNamespace string
+	LeaseDuration  time.Duration
+	RenewDeadline  time.Duration
+	RetryPeriod    time.Duration
 
 	OutOfCluster bool
 	KubeConfig   string
@@ -29,32 +82,74 @@ type SyncConfig struct {
 
 type Controller struct {
 	SyncConfig *SyncConfig
-	Context    context.Context
+
+	// Context is canceled when the controller is asked to shut down
+	Context context.Context
+
+	// RequestContext is used for API requests made by the replicators, so that in-flight
+	// replications can complete after Context is canceled.
+	RequestContext context.Context
+	cancelRequests context.CancelFunc
 
 	ClientConfig  *rest.Config
 	DefaultClient kubernetes.Interface
-	TraefikClient *versioned.Clientset
+	TraefikClient versioned.Interface
+	GatewayClient gatewayversioned.Interface
+	DynamicClient dynamicclient.Interface
+
+	InformerFactory        informers.SharedInformerFactory
+	TraefikInformerFactory traefikinformers.SharedInformerFactory
+	GatewayInformerFactory gatewayinformers.SharedInformerFactory
+	DynamicInformerFactory dynamicinformer.DynamicSharedInformerFactory
+	NamespaceWatcher       *common.NamespaceWatcher
+
+	// SecretInformerFactory only watches TLS Secrets, so that other Secrets are not cached
+	SecretInformerFactory informers.SharedInformerFactory
 
-	ServiceReplicator             common.Replicator
-	IngressReplicator             common.Replicator
-	TraefikIngressRouteReplicator common.Replicator
+	ServiceReplicator                common.Replicator
+	IngressReplicator                common.Replicator
+	TraefikIngressRouteReplicator    common.Replicator
+	TraefikMiddlewareReplicator      common.Replicator
+	TraefikIngressRouteTCPReplicator common.Replicator
+	TraefikIngressRouteUDPReplicator common.Replicator
+	TraefikServiceReplicator         common.Replicator
+	SecretReplicator                 common.Replicator
+	ConfigMapReplicator              common.Replicator
+	GatewayHTTPRouteReplicator       common.Replicator
+	GatewayGRPCRouteReplicator       common.Replicator
+	GatewayTLSRouteReplicator        common.Replicator
+	IstioVirtualServiceReplicator    common.Replicator
+	IstioDestinationRuleReplicator   common.Replicator
+
+	// TraefikIO replicators replicate the traefik.io resources of Traefik v2.10 and later with the dynamic client
+	TraefikIOIngressRouteReplicator    common.Replicator
+	TraefikIOMiddlewareReplicator      common.Replicator
+	TraefikIOServiceReplicator         common.Replicator
+	TraefikIOIngressRouteTCPReplicator common.Replicator
+	TraefikIOIngressRouteUDPReplicator common.Replicator
+
+	// DynamicReplicators replicate the resources configured with SyncConfig.DynamicResources
+	DynamicReplicators []common.Replicator
 }
 
 func NewController() *Controller {
 	return new(Controller)
 }
 
-func (c *Controller) Initialize(config *SyncConfig) (*Controller, error) {
+func (c *Controller) Initialize(ctx context.Context, config *SyncConfig) (*Controller, error) {
 	controller := new(Controller)
 
 	controller.SyncConfig = config
-	controller.Context = context.Background()
+	controller.Context = ctx
+	controller.RequestContext, controller.cancelRequests = context.WithCancel(context.Background())
 
 	if err := controller.InitializeClients(); err != nil {
 		return nil, err
 	}
 
-	controller.InitializeReplicators()
+	if err := controller.InitializeReplicators(); err != nil {
+		return nil, err
+	}
 
 	return controller, nil
 }
@@ -74,6 +169,18 @@ func (c *Controller) InitializeClients() (err error) {
 		}
 	}
 
+	if c.SyncConfig.EnableGatewayAPI {
+		if err := c.InitializeGatewayClient(); err != nil {
+			return err
+		}
+	}
+
+	if c.SyncConfig.EnableTraefik || c.SyncConfig.EnableIstio || len(c.SyncConfig.CertificateIssuer.Name) > 0 || len(c.SyncConfig.DynamicResources) > 0 {
+		if err := c.InitializeDynamicClient(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -87,6 +194,16 @@ func (c *Controller) InitializeTraefikClient() (err error) {
 	return err
 }
 
+func (c *Controller) InitializeGatewayClient() (err error) {
+	c.GatewayClient, err = gatewayversioned.NewForConfig(c.ClientConfig)
+	return err
+}
+
+func (c *Controller) InitializeDynamicClient() (err error) {
+	c.DynamicClient, err = dynamicclient.NewForConfig(c.ClientConfig)
+	return err
+}
+
 func (c *Controller) InitializeClusterConfig() (err error) {
 	if c.SyncConfig.OutOfCluster {
 		c.ClientConfig, err = clientcmd.BuildConfigFromFlags("", c.SyncConfig.KubeConfig)
@@ -97,11 +214,215 @@ func (c *Controller) InitializeClusterConfig() (err error) {
 	return
 }
 
-func (c *Controller) InitializeReplicators() {
-	c.ServiceReplicator = service.NewReplicator(c.Context, c.DefaultClient, c.SyncConfig.ResyncPeriod)
-	c.IngressReplicator = ingress.NewReplicator(c.Context, c.DefaultClient, c.SyncConfig.ResyncPeriod, c.SyncConfig.DefaultIngressHostname)
+func (c *Controller) InitializeReplicators() error {
+	c.InformerFactory = informers.NewSharedInformerFactory(c.DefaultClient, c.SyncConfig.ResyncPeriod)
+	c.NamespaceWatcher = common.NewNamespaceWatcher(c.InformerFactory.Core().V1().Namespaces())
+
+	config := common.ReplicatorConfig{
+		Client:                 c.DefaultClient,
+		TraefikClient:          c.TraefikClient,
+		GatewayClient:          c.GatewayClient,
+		DynamicClient:          c.DynamicClient,
+		Workers:                c.SyncConfig.Workers,
+		DefaultIngressHostname: c.SyncConfig.DefaultIngressHostname,
+		HostTemplate:           c.SyncConfig.HostTemplate,
+		MaxHostLabelLength:     c.SyncConfig.MaxHostLabelLength,
+		NamespaceWatcher:       c.NamespaceWatcher,
+		ServerSideApply:        c.SyncConfig.ServerSideApply,
+		ServiceMesh:            c.SyncConfig.ServiceMesh,
+		ClusterDomain:          c.SyncConfig.ClusterDomain,
+		TraefikRuleSyntax:      c.SyncConfig.TraefikRuleSyntax,
+		CertificateIssuer:      c.SyncConfig.CertificateIssuer,
+
+		OrphanCollectionInterval: c.SyncConfig.OrphanCollectionInterval,
+		OrphanPolicy:             c.SyncConfig.OrphanPolicy,
+	}
+
+	c.ServiceReplicator = service.NewReplicator(c.RequestContext, config, c.InformerFactory.Core().V1().Services())
+	c.IngressReplicator = ingress.NewReplicator(c.RequestContext, config, c.InformerFactory.Networking().V1().Ingresses())
 
+	if c.SyncConfig.EnableTraefik || c.SyncConfig.EnableIstio || len(c.SyncConfig.DynamicResources) > 0 {
+		c.DynamicInformerFactory = dynamicinformer.NewDynamicSharedInformerFactory(c.DynamicClient, c.SyncConfig.ResyncPeriod)
+	}
+
+	configured := make(map[schema.GroupVersionResource]struct{})
 	if c.SyncConfig.EnableTraefik {
-		c.TraefikIngressRouteReplicator = ingressroute.NewReplicator(c.Context, c.DefaultClient, c.TraefikClient, c.SyncConfig.ResyncPeriod, c.SyncConfig.DefaultIngressHostname)
+		if err := c.InitializeTraefikReplicators(config, configured); err != nil {
+			return err
+		}
 	}
+
+	if c.SyncConfig.EnableGatewayAPI {
+		c.GatewayInformerFactory = gatewayinformers.NewSharedInformerFactory(c.GatewayClient, c.SyncConfig.ResyncPeriod)
+		referenceGrants := c.GatewayInformerFactory.Gateway().V1beta1().ReferenceGrants()
+		c.GatewayHTTPRouteReplicator = httproute.NewReplicator(c.RequestContext, config, c.GatewayInformerFactory.Gateway().V1beta1().HTTPRoutes(), referenceGrants)
+
+		if c.SyncConfig.EnableGatewayAPIAlpha {
+			c.GatewayGRPCRouteReplicator = grpcroute.NewReplicator(c.RequestContext, config, c.GatewayInformerFactory.Gateway().V1alpha2().GRPCRoutes(), referenceGrants)
+			c.GatewayTLSRouteReplicator = tlsroute.NewReplicator(c.RequestContext, config, c.GatewayInformerFactory.Gateway().V1alpha2().TLSRoutes(), referenceGrants)
+		}
+	}
+
+	if c.SyncConfig.EnableConfigMaps {
+		c.ConfigMapReplicator = configmap.NewReplicator(c.RequestContext, config, c.InformerFactory.Core().V1().ConfigMaps())
+	}
+
+	if c.SyncConfig.EnableTLSSecrets {
+		c.SecretInformerFactory = informers.NewSharedInformerFactoryWithOptions(c.DefaultClient, c.SyncConfig.ResyncPeriod,
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = fields.OneTermEqualSelector("type", string(v1.SecretTypeTLS)).String()
+			}),
+		)
+		c.SecretReplicator = secret.NewReplicator(c.RequestContext, config, c.SecretInformerFactory.Core().V1().Secrets(),
+			c.IngressReplicator, c.TraefikIngressRouteReplicator, c.TraefikIngressRouteTCPReplicator, c.TraefikIOIngressRouteReplicator, c.TraefikIOIngressRouteTCPReplicator,
+			c.TraefikMiddlewareReplicator, c.TraefikIOMiddlewareReplicator,
+		)
+	}
+
+	if c.SyncConfig.EnableIstio {
+		c.IstioVirtualServiceReplicator = virtualservice.NewReplicator(c.RequestContext, config, c.DynamicInformerFactory.ForResource(istio.VirtualServices))
+		c.IstioDestinationRuleReplicator = destinationrule.NewReplicator(c.RequestContext, config, c.DynamicInformerFactory.ForResource(istio.DestinationRules))
+		configured[istio.VirtualServices] = struct{}{}
+		configured[istio.DestinationRules] = struct{}{}
+	}
+
+	for _, resource := range c.SyncConfig.DynamicResources {
+		gvr := resource.GroupVersionResource()
+		if _, ok := configured[gvr]; ok {
+			return fmt.Errorf("dynamic resource %s is configured more than once or already replicated with --enable-traefik or --enable-istio", gvr)
+		}
+		configured[gvr] = struct{}{}
+
+		replicator, err := dynamic.NewReplicator(c.RequestContext, config, resource, c.DynamicInformerFactory.ForResource(gvr))
+		if err != nil {
+			return err
+		}
+		c.DynamicReplicators = append(c.DynamicReplicators, replicator)
+	}
+
+	return nil
+}
+
+// InitializeTraefikReplicators creates the Traefik replicators of the API groups whose IngressRoute CRD is installed.
+// The traefik.containo.us resources are replicated with the Traefik clientset, the traefik.io resources of Traefik
+// v2.10 and later with the dynamic client.
+func (c *Controller) InitializeTraefikReplicators(config common.ReplicatorConfig, configured map[schema.GroupVersionResource]struct{}) error {
+	served, err := traefik.ServedGroupVersions(c.DefaultClient.Discovery())
+	if err != nil {
+		return err
+	}
+	if len(served) == 0 {
+		return fmt.Errorf("--enable-traefik is set, but the IngressRoute CRD of neither %s nor %s is installed", traefik.ContainousGroupVersion, traefik.GroupVersion)
+	}
+
+	if len(config.TraefikRuleSyntax) == 0 {
+		config.TraefikRuleSyntax = traefik.DefaultRuleSyntax(served)
+	}
+
+	for _, gv := range served {
+		log.Infof("replicating Traefik resources of %s", gv)
+
+		switch gv {
+		case traefik.ContainousGroupVersion:
+			c.TraefikInformerFactory = traefikinformers.NewSharedInformerFactory(c.TraefikClient, c.SyncConfig.ResyncPeriod)
+			c.TraefikIngressRouteReplicator = ingressroute.NewReplicator(c.RequestContext, config, c.TraefikInformerFactory.Traefik().V1alpha1().IngressRoutes())
+			c.TraefikMiddlewareReplicator = middleware.NewReplicator(c.RequestContext, config, c.TraefikInformerFactory.Traefik().V1alpha1().Middlewares(),
+				c.TraefikIngressRouteReplicator,
+			)
+			c.TraefikIngressRouteTCPReplicator = ingressroutetcp.NewReplicator(c.RequestContext, config, c.TraefikInformerFactory.Traefik().V1alpha1().IngressRouteTCPs())
+			c.TraefikIngressRouteUDPReplicator = ingressrouteudp.NewReplicator(c.RequestContext, config, c.TraefikInformerFactory.Traefik().V1alpha1().IngressRouteUDPs())
+			c.TraefikServiceReplicator = traefikservice.NewReplicator(c.RequestContext, config, c.TraefikInformerFactory.Traefik().V1alpha1().TraefikServices(),
+				c.TraefikIngressRouteReplicator,
+			)
+		case traefik.GroupVersion:
+			log.Infof("rewriting the rules of %s IngressRoutes in the %s syntax unless their route sets one", gv, config.TraefikRuleSyntax)
+			c.TraefikIOIngressRouteReplicator = ingressroute.NewUnstructuredReplicator(c.RequestContext, config, c.DynamicInformerFactory.ForResource(traefik.IngressRoutes))
+			c.TraefikIOMiddlewareReplicator = middleware.NewUnstructuredReplicator(c.RequestContext, config, c.DynamicInformerFactory.ForResource(traefik.Middlewares),
+				c.TraefikIOIngressRouteReplicator,
+			)
+			c.TraefikIOServiceReplicator = traefikservice.NewUnstructuredReplicator(c.RequestContext, config, c.DynamicInformerFactory.ForResource(traefik.TraefikServices),
+				c.TraefikIOIngressRouteReplicator,
+			)
+			c.TraefikIOIngressRouteTCPReplicator = ingressroutetcp.NewUnstructuredReplicator(c.RequestContext, config, c.DynamicInformerFactory.ForResource(traefik.IngressRouteTCPs))
+			c.TraefikIOIngressRouteUDPReplicator = ingressrouteudp.NewUnstructuredReplicator(c.RequestContext, config, c.DynamicInformerFactory.ForResource(traefik.IngressRouteUDPs))
+			configured[traefik.IngressRoutes] = struct{}{}
+			configured[traefik.IngressRouteTCPs] = struct{}{}
+			configured[traefik.IngressRouteUDPs] = struct{}{}
+			configured[traefik.Middlewares] = struct{}{}
+			configured[traefik.TraefikServices] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// StartInformers starts all shared informers requested by the replicators
+func (c *Controller) StartInformers(stopCh <-chan struct{}) {
+	c.InformerFactory.Start(stopCh)
+
+	if c.TraefikInformerFactory != nil {
+		c.TraefikInformerFactory.Start(stopCh)
+	}
+
+	if c.GatewayInformerFactory != nil {
+		c.GatewayInformerFactory.Start(stopCh)
+	}
+
+	if c.SecretInformerFactory != nil {
+		c.SecretInformerFactory.Start(stopCh)
+	}
+
+	if c.DynamicInformerFactory != nil {
+		c.DynamicInformerFactory.Start(stopCh)
+	}
+}
+
+// RunReplicators runs all initialized replicators and blocks until they have stopped after the context is canceled
+func (c *Controller) RunReplicators(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, replicator := range c.Replicators() {
+		if replicator == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(replicator common.Replicator) {
+			defer wg.Done()
+			replicator.Run(ctx)
+		}(replicator)
+	}
+
+	wg.Wait()
+}
+
+// Replicators returns all replicators of the controller, including those that are not enabled
+func (c *Controller) Replicators() []common.Replicator {
+	replicators := []common.Replicator{
+		c.ServiceReplicator,
+		c.IngressReplicator,
+		c.TraefikIngressRouteReplicator,
+		c.TraefikMiddlewareReplicator,
+		c.TraefikIngressRouteTCPReplicator,
+		c.TraefikIngressRouteUDPReplicator,
+		c.TraefikServiceReplicator,
+		c.SecretReplicator,
+		c.ConfigMapReplicator,
+		c.GatewayHTTPRouteReplicator,
+		c.GatewayGRPCRouteReplicator,
+		c.GatewayTLSRouteReplicator,
+		c.IstioVirtualServiceReplicator,
+		c.IstioDestinationRuleReplicator,
+		c.TraefikIOIngressRouteReplicator,
+		c.TraefikIOMiddlewareReplicator,
+		c.TraefikIOServiceReplicator,
+		c.TraefikIOIngressRouteTCPReplicator,
+		c.TraefikIOIngressRouteUDPReplicator,
+	}
+
+	return append(replicators, c.DynamicReplicators...)
+}
+
+// Shutdown cancels any API requests that are still in-flight
+func (c *Controller) Shutdown() {
+	c.cancelRequests()
 }
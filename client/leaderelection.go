@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// DefaultLeaseName is the name of the Lease used for leader election when none is configured.
+const DefaultLeaseName = "kube-external-sync"
+
+// RunWithLeaderElection blocks while competing for the leader Lease and calls run once this replica becomes the leader.
+// Once the context is canceled, the Lease is released after run has returned.
+func (c *Controller) RunWithLeaderElection(ctx context.Context, run func(ctx context.Context)) error {
+	lock, err := c.leaseLock()
+	if err != nil {
+		return err
+	}
+
+	logger := log.WithField("lease", fmt.Sprintf("%s/%s", lock.LeaseMeta.Namespace, lock.LeaseMeta.Name)).WithField("identity", lock.Identity())
+	logger.Info("starting leader election")
+
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+
+	// the Lease is only released once the replicators have drained, so that a standby cannot take over early
+	electionCtx, cancelElection := context.WithCancel(context.Background())
+	defer cancelElection()
+	go func() {
+		<-ctx.Done()
+
+		select {
+		case <-started:
+			<-stopped
+		default:
+		}
+
+		cancelElection()
+	}()
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   c.SyncConfig.LeaseDuration,
+		RenewDeadline:   c.SyncConfig.RenewDeadline,
+		RetryPeriod:     c.SyncConfig.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            lock.LeaseMeta.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				close(started)
+				defer close(stopped)
+
+				logger.Info("started leading, starting replicators")
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					logger.Info("shutting down, releasing leadership")
+					return
+				}
+
+				// replicators cannot safely keep writing once another replica may have taken over
+				logger.Fatal("stopped leading")
+			},
+			OnNewLeader: func(identity string) {
+				if identity != lock.Identity() {
+					logger.Infof("current leader is %s", identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	elector.Run(electionCtx)
+	return nil
+}
+
+func (c *Controller) leaseLock() (*resourcelock.LeaseLock, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := c.SyncConfig.LeaseNamespace
+	if len(namespace) == 0 {
+		namespace = c.SyncConfig.PodNamespace
+	}
+	if len(namespace) == 0 {
+		return nil, fmt.Errorf("a lease namespace or pod namespace is required for leader election")
+	}
+
+	name := c.SyncConfig.LeaseName
+	if len(name) == 0 {
+		name = DefaultLeaseName
+	}
+
+	return &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Client: c.DefaultClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: fmt.Sprintf("%s_%s", hostname, uuid.NewUUID()),
+		},
+	}, nil
+}
@@ -1,20 +1,55 @@
 package liveness
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/alehechka/kube-external-sync/client/replicate/common"
 	log "github.com/sirupsen/logrus"
 )
 
-func Serve(port int, replicators []common.Replicator) error {
+// Serve runs the liveness monitor until the context is canceled, after which the server is shut down within the
+// provided timeout.
+func Serve(ctx context.Context, port int, shutdownTimeout time.Duration, replicators []common.Replicator) error {
 	h := Handler{
 		Replicators: replicators,
 	}
 
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", &h)
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: mux,
+	}
+
 	log.Infof("starting liveness monitor on port: %d", port)
 
-	http.Handle("/healthz", &h)
-	return http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("shutting down liveness monitor")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,189 @@
+package certmanager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// GroupVersion is the cert-manager API version of the managed Certificates
+var GroupVersion = schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}
+
+// CertificatesResource is the resource of cert-manager Certificates
+var CertificatesResource = GroupVersion.WithResource("certificates")
+
+// Issuer kinds of cert-manager
+const (
+	IssuerKind        = "Issuer"
+	ClusterIssuerKind = "ClusterIssuer"
+)
+
+// Certificates manages the cert-manager Certificates of the replicas of a replicator, which are issued for the hosts
+// of the replicas and stored in the Secret that their TLS configuration references
+type Certificates struct {
+	*common.GenericReplicator
+
+	// DNSNames lists the hosts of the replica of the source in the provided namespace that a Certificate is issued for.
+	// No Certificate is issued if it is empty.
+	DNSNames func(namespace string, source interface{}) []string
+}
+
+// NewCertificates manages the Certificates of the replicas of the provided replicator. Its UpdateFuncs are wrapped, so
+// that the Certificate of a replica is synced after it is replicated and deleted after the replica is deleted.
+func NewCertificates(repl *common.GenericReplicator, dnsNames func(namespace string, source interface{}) []string) *Certificates {
+	certificates := &Certificates{
+		GenericReplicator: repl,
+		DNSNames:          dnsNames,
+	}
+
+	replicateObjectTo := repl.UpdateFuncs.ReplicateObjectTo
+	repl.UpdateFuncs.ReplicateObjectTo = func(source interface{}, target *v1.Namespace) error {
+		if err := replicateObjectTo(source, target); err != nil {
+			return err
+		}
+
+		return certificates.Sync(source, target.Name)
+	}
+
+	deleteReplicatedResource := repl.UpdateFuncs.DeleteReplicatedResource
+	repl.UpdateFuncs.DeleteReplicatedResource = func(target interface{}) error {
+		if err := deleteReplicatedResource(target); err != nil {
+			return err
+		}
+
+		replica := common.MustGetObject(target)
+		return certificates.delete(replica.GetNamespace(), CertificateName(repl.Kind, replica.GetName()))
+	}
+
+	return certificates
+}
+
+// Issues checks whether a Certificate is issued for the replicas of the source. This requires a configured issuer and
+// hosts that are rewritten to a single top-level-domain, either by the TopLevelDomain annotation or the default ingress
+// hostname, for which no Secret is provided with the TLDSecretName annotation.
+func Issues(config common.ReplicatorConfig, source metav1.Object) bool {
+	if len(config.CertificateIssuer.Name) == 0 {
+		return false
+	}
+
+	annotations := source.GetAnnotations()
+	if _, ok := annotations[common.TLDSecretName]; ok {
+		return false
+	}
+
+	_, ok := annotations[common.TopLevelDomain]
+	return ok || len(config.DefaultIngressHostname) > 0
+}
+
+// CertificateName is the name of the Certificate, and the Secret it is stored in, of a replicated resource
+func CertificateName(kind, name string) string {
+	return fmt.Sprintf("%s-%s-tls", strings.ToLower(kind), name)
+}
+
+// client is the dynamic Certificate client of the provided namespace
+func (c *Certificates) client(namespace string) dynamic.ResourceInterface {
+	return c.DynamicClient.Resource(CertificatesResource).Namespace(namespace)
+}
+
+// Sync creates or updates the Certificate of the replica of the source in the namespace, or deletes it if the replica
+// no longer needs a Certificate. Certificates that are not managed by this controller are never modified.
+func (c *Certificates) Sync(sourceObj interface{}, namespace string) error {
+	source := common.MustGetObject(sourceObj)
+	name := CertificateName(c.Kind, source.GetName())
+
+	logger := log.WithField("kind", "Certificate").WithField("source", common.MustGetKey(source)).WithField("target", fmt.Sprintf("%s/%s", namespace, name))
+
+	dnsNames := c.DNSNames(namespace, sourceObj)
+	if len(dnsNames) == 0 {
+		return c.delete(namespace, name)
+	}
+
+	prepared := c.prepareCertificate(namespace, name, source, dnsNames)
+
+	existing, err := c.client(namespace).Get(c.Context, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logger.Infof("Issuing certificate for %s", strings.Join(dnsNames, ", "))
+		if _, err := c.client(namespace).Create(c.Context, prepared, common.CreateOptions()); err != nil {
+			return errors.Wrapf(err, "Failed creating certificate %s/%s", namespace, name)
+		}
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "Failed getting certificate %s/%s", namespace, name)
+	}
+
+	if !common.IsManagedBy(existing) {
+		logger.Debugf("certificate is not managed and will not be synced")
+		return nil
+	}
+
+	if common.ReplicatedHashEqual(prepared, existing) && !common.UnstructuredDrifted(prepared, existing) {
+		return nil
+	}
+
+	prepared.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := c.client(namespace).Update(c.Context, prepared, common.UpdateOptions()); err != nil {
+		return errors.Wrapf(err, "Failed updating certificate %s/%s", namespace, name)
+	}
+	return nil
+}
+
+// delete deletes the Certificate if it exists and is managed by this controller
+func (c *Certificates) delete(namespace, name string) error {
+	existing, err := c.client(namespace).Get(c.Context, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "Failed getting certificate %s/%s", namespace, name)
+	}
+
+	if !common.IsManagedBy(existing) {
+		return nil
+	}
+
+	if err := c.client(namespace).Delete(c.Context, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "Failed deleting certificate %s/%s", namespace, name)
+	}
+	return nil
+}
+
+// prepareCertificate builds the Certificate for the DNS names, issued by the configured issuer
+func (c *Certificates) prepareCertificate(namespace, name string, source metav1.Object, dnsNames []string) *unstructured.Unstructured {
+	names := make([]interface{}, 0, len(dnsNames))
+	for _, dnsName := range dnsNames {
+		names = append(names, dnsName)
+	}
+
+	kind := c.CertificateIssuer.Kind
+	if len(kind) == 0 {
+		kind = ClusterIssuerKind
+	}
+
+	prepared := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"secretName": name,
+			"dnsNames":   names,
+			"issuerRef": map[string]interface{}{
+				"group": GroupVersion.Group,
+				"kind":  kind,
+				"name":  c.CertificateIssuer.Name,
+			},
+		},
+	}}
+	prepared.SetGroupVersionKind(GroupVersion.WithKind("Certificate"))
+	prepared.SetName(name)
+	prepared.SetNamespace(namespace)
+	prepared.SetLabels(map[string]string{common.ManagedByLabelKey: common.ManagedByLabelValue})
+	prepared.SetAnnotations(map[string]string{common.ReplicatedFromAnnotation: common.MustGetKey(source)})
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
@@ -0,0 +1,139 @@
+package certmanager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newTestCertificates(objects ...runtime.Object) (*Certificates, *dynamicfake.FakeDynamicClient, *[]string) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{CertificatesResource: "CertificateList"}, objects...)
+
+	repl := &common.GenericReplicator{
+		ReplicatorConfig: common.ReplicatorConfig{
+			Kind:              "Ingress",
+			DynamicClient:     client,
+			CertificateIssuer: common.CertificateIssuer{Name: "letsencrypt", Kind: ClusterIssuerKind},
+		},
+		Context: context.Background(),
+	}
+
+	var calls []string
+	repl.UpdateFuncs = common.UpdateFuncs{
+		ReplicateObjectTo: func(source interface{}, target *v1.Namespace) error {
+			calls = append(calls, "replicate")
+			return nil
+		},
+		DeleteReplicatedResource: func(target interface{}) error {
+			calls = append(calls, "delete")
+			return nil
+		},
+	}
+
+	dnsNames := func(namespace string, source interface{}) []string {
+		if !Issues(repl.ReplicatorConfig, common.MustGetObject(source)) {
+			return nil
+		}
+		return []string{common.PrepareTLD(namespace, common.MustGetObject(source).GetAnnotations()[common.TopLevelDomain])}
+	}
+
+	return NewCertificates(repl, dnsNames), client, &calls
+}
+
+func getCertificate(t *testing.T, client *dynamicfake.FakeDynamicClient, namespace, name string) *unstructured.Unstructured {
+	certificate, err := client.Resource(CertificatesResource).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return certificate
+}
+
+func Test_Certificates(t *testing.T) {
+	source := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "nginx",
+		Namespace:   "default",
+		Annotations: map[string]string{common.TopLevelDomain: "app.example.com"},
+	}}
+	target := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-a"}}
+
+	certificates, client, calls := newTestCertificates()
+
+	// issued after the replica is replicated
+	assert.NoError(t, certificates.UpdateFuncs.ReplicateObjectTo(source, target))
+	assert.Equal(t, []string{"replicate"}, *calls)
+
+	certificate := getCertificate(t, client, "feature-a", "ingress-nginx-tls")
+	assert.True(t, common.IsManagedBy(certificate))
+	assert.Equal(t, "default/nginx", certificate.GetAnnotations()[common.ReplicatedFromAnnotation])
+	assert.Equal(t, map[string]interface{}{
+		"secretName": "ingress-nginx-tls",
+		"dnsNames":   []interface{}{"feature-a.example.com"},
+		"issuerRef":  map[string]interface{}{"group": "cert-manager.io", "kind": "ClusterIssuer", "name": "letsencrypt"},
+	}, certificate.Object["spec"])
+
+	// updated when the hosts change
+	source.Annotations[common.TopLevelDomain] = "web.example.org"
+	assert.NoError(t, certificates.Sync(source, "feature-a"))
+	certificate = getCertificate(t, client, "feature-a", "ingress-nginx-tls")
+	dnsNames, _, _ := unstructured.NestedStringSlice(certificate.Object, "spec", "dnsNames")
+	assert.Equal(t, []string{"feature-a.example.org"}, dnsNames)
+
+	// deleted when a Secret is provided instead
+	source.Annotations[common.TLDSecretName] = "wildcard"
+	assert.NoError(t, certificates.Sync(source, "feature-a"))
+	_, err := client.Resource(CertificatesResource).Namespace("feature-a").Get(context.Background(), "ingress-nginx-tls", metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// deleted after the replica is deleted
+	delete(source.Annotations, common.TLDSecretName)
+	assert.NoError(t, certificates.Sync(source, "feature-a"))
+	replica := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "feature-a"}}
+	assert.NoError(t, certificates.UpdateFuncs.DeleteReplicatedResource(replica))
+	assert.Equal(t, []string{"replicate", "delete"}, *calls)
+	_, err = client.Resource(CertificatesResource).Namespace("feature-a").Get(context.Background(), "ingress-nginx-tls", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func Test_Certificates_Unmanaged(t *testing.T) {
+	unmanaged := &unstructured.Unstructured{}
+	unmanaged.SetGroupVersionKind(GroupVersion.WithKind("Certificate"))
+	unmanaged.SetName("ingress-nginx-tls")
+	unmanaged.SetNamespace("feature-a")
+
+	source := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "nginx",
+		Namespace:   "default",
+		Annotations: map[string]string{common.TopLevelDomain: "app.example.com"},
+	}}
+
+	certificates, client, _ := newTestCertificates(unmanaged)
+	assert.NoError(t, certificates.Sync(source, "feature-a"))
+	assert.NoError(t, certificates.UpdateFuncs.DeleteReplicatedResource(&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "feature-a"}}))
+
+	certificate := getCertificate(t, client, "feature-a", "ingress-nginx-tls")
+	assert.False(t, common.IsManagedBy(certificate))
+	assert.NotContains(t, certificate.Object, "spec")
+}
+
+func Test_Issues(t *testing.T) {
+	config := common.ReplicatorConfig{CertificateIssuer: common.CertificateIssuer{Name: "letsencrypt"}}
+	tld := &metav1.ObjectMeta{Annotations: map[string]string{common.TopLevelDomain: "app.example.com"}}
+
+	assert.True(t, Issues(config, tld))
+	assert.False(t, Issues(config, &metav1.ObjectMeta{}))
+	assert.False(t, Issues(config, &metav1.ObjectMeta{Annotations: map[string]string{
+		common.TopLevelDomain: "app.example.com",
+		common.TLDSecretName:  "wildcard",
+	}}))
+	assert.False(t, Issues(common.ReplicatorConfig{}, tld))
+
+	config.DefaultIngressHostname = "app.example.com"
+	assert.True(t, Issues(config, &metav1.ObjectMeta{}))
+}
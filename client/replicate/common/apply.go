@@ -0,0 +1,48 @@
+package common
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// FieldManager is the name of the field manager used for all writes by this controller
+const FieldManager = "kube-external-sync"
+
+// CreateOptions are the options used when creating replicated resources
+func CreateOptions() metav1.CreateOptions {
+	return metav1.CreateOptions{FieldManager: FieldManager}
+}
+
+// UpdateOptions are the options used when updating replicated resources
+func UpdateOptions() metav1.UpdateOptions {
+	return metav1.UpdateOptions{FieldManager: FieldManager}
+}
+
+// ApplyOptions are the options used when server-side applying replicated resources.
+// Conflicts are forced, as replicated resources are owned by this controller.
+func ApplyOptions() metav1.PatchOptions {
+	force := true
+	return metav1.PatchOptions{FieldManager: FieldManager, Force: &force}
+}
+
+// PrepareApplyPatch marshals the prepared resource into a server-side apply patch of the provided kind.
+// The status and unset server-managed fields are left out, so that they are not owned by this controller.
+func PrepareApplyPatch(prepared runtime.Object, gvk schema.GroupVersionKind) ([]byte, error) {
+	prepared.GetObjectKind().SetGroupVersionKind(gvk)
+
+	patch, err := runtime.DefaultUnstructuredConverter.ToUnstructured(prepared)
+	if err != nil {
+		return nil, err
+	}
+
+	unstructured.RemoveNestedField(patch, "status")
+	if timestamp, _, _ := unstructured.NestedFieldNoCopy(patch, "metadata", "creationTimestamp"); timestamp == nil {
+		unstructured.RemoveNestedField(patch, "metadata", "creationTimestamp")
+	}
+
+	return json.Marshal(patch)
+}
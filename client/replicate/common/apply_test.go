@@ -0,0 +1,29 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_PrepareApplyPatch(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "feature-0"},
+		Spec:       v1.ServiceSpec{Type: v1.ServiceTypeExternalName, ExternalName: "nginx.default.svc.cluster.local"},
+	}
+
+	data, err := PrepareApplyPatch(service, v1.SchemeGroupVersion.WithKind("Service"))
+	assert.NoError(t, err)
+
+	patch := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(data, &patch))
+
+	assert.Equal(t, "v1", patch["apiVersion"])
+	assert.Equal(t, "Service", patch["kind"])
+	assert.NotContains(t, patch, "status")
+	assert.Equal(t, map[string]interface{}{"name": "nginx", "namespace": "feature-0"}, patch["metadata"])
+	assert.Equal(t, map[string]interface{}{"type": "ExternalName", "externalName": "nginx.default.svc.cluster.local"}, patch["spec"])
+}
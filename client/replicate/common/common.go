@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -9,7 +10,7 @@ import (
 )
 
 type Replicator interface {
-	Run()
+	Run(ctx context.Context)
 	Synced() bool
 	NamespaceAdded(ns *v1.Namespace)
 }
@@ -37,7 +38,6 @@ func PrepareAnnotations(source metav1.ObjectMeta) map[string]string {
 
 	annotations[ReplicatedFromAnnotation] = fmt.Sprintf("%s/%s", source.Namespace, source.Name)
 	annotations[ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
-	annotations[ReplicatedFromVersionAnnotation] = source.ResourceVersion
 
 	return annotations
 }
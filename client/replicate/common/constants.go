@@ -18,16 +18,26 @@ const (
 	TopLevelDomain      = "kube-external-sync.io/top-level-domain"
 	TLDSecretName       = "kube-external-sync.io/tld-secret-name"
 	ExternalNameSuffix  = "kube-external-sync.io/external-name-suffix"
+	ServiceMesh         = "kube-external-sync.io/service-mesh"
 	KeepOwnerReferences = "kube-external-sync.io/keep-owner-references"
+	RewriteHosts        = "kube-external-sync.io/rewrite-hosts"
+	MiddlewareRefs      = "kube-external-sync.io/middleware-refs"
+	HostTemplate        = "kube-external-sync.io/host-template"
 )
 
 // Annotations that are added to replicated resources by this Controller
 const (
-	ReplicatedFromAnnotation        = "kube-external-sync.io/replicated-from"
-	ReplicatedAtAnnotation          = "kube-external-sync.io/replicated-at"
-	ReplicatedFromVersionAnnotation = "kube-external-sync.io/replicated-from-version"
+	ReplicatedFromAnnotation = "kube-external-sync.io/replicated-from"
+	ReplicatedAtAnnotation   = "kube-external-sync.io/replicated-at"
+	ReplicatedHashAnnotation = "kube-external-sync.io/replicated-hash"
+	ShortenedHostsAnnotation = "kube-external-sync.io/shortened-hosts"
+	InvalidHostsAnnotation   = "kube-external-sync.io/invalid-hosts"
 )
 
+// IgnoreDrift is an annotation that can be added to replicated resources that are intentionally diverged from their source.
+// Replicated resources with this annotation are no longer updated by this Controller.
+const IgnoreDrift = "kube-external-sync.io/ignore-drift"
+
 // DefaultStripAnnotations contains the annotations that are to be stripped when replicating a resource
 var DefaultStripAnnotations = map[string]struct{}{
 	LastAppliedConfigurationAnnotationKey: {},
@@ -37,10 +47,36 @@ var DefaultStripAnnotations = map[string]struct{}{
 	StripAnnotations:                      {},
 	TopLevelDomain:                        {},
 	TLDSecretName:                         {},
+	IgnoreDrift:                           {},
+	RewriteHosts:                          {},
+	MiddlewareRefs:                        {},
+	HostTemplate:                          {},
+	InvalidHostsAnnotation:                {},
 }
 
+// MiddlewareRefs options
+const (
+	// MiddlewareRefsLocal keeps middleware references as-is, so that they resolve to middlewares replicated alongside the IngressRoute
+	MiddlewareRefsLocal = "local"
+	// MiddlewareRefsSource points middleware references without a namespace to the namespace of the source IngressRoute
+	MiddlewareRefsSource = "source"
+)
+
 // ExternalName suffix options
 const (
 	DefaultExternalNameSuffix     = "svc.cluster.local"
 	TraefikMeshExternalNameSuffix = "traefik.mesh"
 )
+
+// DefaultClusterDomain is the DNS domain of Kubernetes clusters unless configured otherwise
+const DefaultClusterDomain = "cluster.local"
+
+// ServiceMesh options that select the ExternalName suffix of replicated Services
+const (
+	// ServiceMeshNone points ExternalNames to the cluster DNS name of the source Service
+	ServiceMeshNone = "none"
+	// ServiceMeshTraefik points ExternalNames to the Traefik Mesh name of the source Service
+	ServiceMeshTraefik = "traefik-mesh"
+	// ServiceMeshIstio is an alias of ServiceMeshNone, since Istio registers Services under their cluster DNS name
+	ServiceMeshIstio = "istio"
+)
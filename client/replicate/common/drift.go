@@ -0,0 +1,33 @@
+package common
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IgnoresDrift checks the target's annotations to see if it has opted out of being synced with its source
+func IgnoresDrift(target metav1.Object) bool {
+	ignoreDrift, ok := target.GetAnnotations()[IgnoreDrift]
+
+	return ok && ignoreDrift == "true"
+}
+
+// MetadataDrifted checks whether any of the desired labels or annotations are missing or modified on the target.
+// Additional labels and annotations on the target, such as those added by other controllers, are tolerated.
+func MetadataDrifted(desired metav1.Object, target metav1.Object) bool {
+	return mapDrifted(desired.GetLabels(), target.GetLabels()) ||
+		mapDrifted(desired.GetAnnotations(), target.GetAnnotations())
+}
+
+func mapDrifted(desired map[string]string, actual map[string]string) bool {
+	for key, value := range desired {
+		if key == ReplicatedAtAnnotation {
+			continue
+		}
+
+		if actualValue, ok := actual[key]; !ok || actualValue != value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_MetadataDrifted(t *testing.T) {
+	desired := &metav1.ObjectMeta{
+		Labels: map[string]string{ManagedByLabelKey: ManagedByLabelValue, "app": "nginx"},
+		Annotations: map[string]string{
+			ReplicatedFromAnnotation: "default/nginx",
+			ReplicatedAtAnnotation:   "2023-01-02T00:00:00Z",
+		},
+	}
+
+	tests := []struct {
+		name     string
+		target   *metav1.ObjectMeta
+		expected bool
+	}{
+		{
+			name:   "equal",
+			target: desired,
+		},
+		{
+			name: "replicated-at and additional keys are ignored",
+			target: &metav1.ObjectMeta{
+				Labels: map[string]string{ManagedByLabelKey: ManagedByLabelValue, "app": "nginx", "extra": "true"},
+				Annotations: map[string]string{
+					ReplicatedFromAnnotation:              "default/nginx",
+					ReplicatedAtAnnotation:                "2023-01-01T00:00:00Z",
+					LastAppliedConfigurationAnnotationKey: "{}",
+				},
+			},
+		},
+		{
+			name: "modified label",
+			target: &metav1.ObjectMeta{
+				Labels:      map[string]string{ManagedByLabelKey: ManagedByLabelValue, "app": "apache"},
+				Annotations: desired.Annotations,
+			},
+			expected: true,
+		},
+		{
+			name: "missing annotation",
+			target: &metav1.ObjectMeta{
+				Labels: desired.Labels,
+			},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, MetadataDrifted(desired, test.target))
+		})
+	}
+}
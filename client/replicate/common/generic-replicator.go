@@ -2,34 +2,69 @@ package common
 
 import (
 	"context"
-	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/clientset/versioned"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	gatewayversioned "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 )
 
 // ReplicatorConfig represents configuration for individual resource controllers
 type ReplicatorConfig struct {
 	Kind                   string
 	Client                 kubernetes.Interface
-	TraefikClient          *versioned.Clientset
-	ResyncPeriod           time.Duration
+	TraefikClient          versioned.Interface
+	GatewayClient          gatewayversioned.Interface
+	DynamicClient          dynamic.Interface
+	Workers                int
 	DefaultIngressHostname string
-	ListFunc               cache.ListFunc
-	WatchFunc              cache.WatchFunc
-	ObjType                runtime.Object
+
+	// HostTemplate prepares the hosts of sources without a HostTemplate annotation, if set
+	HostTemplate *template.Template
+	// MaxHostLabelLength is the length that longer labels of prepared hosts are shortened to, DefaultMaxHostLabelLength if zero
+	MaxHostLabelLength int
+
+	// ServiceMesh selects the ExternalName suffix of replicated Services without an ExternalNameSuffix or ServiceMesh annotation
+	ServiceMesh string
+	// ClusterDomain is the DNS domain of the cluster used in ExternalNames outside of Traefik Mesh
+	ClusterDomain string
+
+	// TraefikRuleSyntax is the syntax of the rules of traefik.io IngressRoutes whose routes do not set one
+	TraefikRuleSyntax string
+
+	// CertificateIssuer issues cert-manager Certificates for the hosts of replicated Ingresses and IngressRoutes, if set
+	CertificateIssuer CertificateIssuer
+
+	// ServerSideApply writes replicated resources with server-side apply, so that fields set by other controllers are kept
+	ServerSideApply bool
+
+	// OrphanCollectionInterval is the period between sweeps for orphaned replicas, a single sweep is run at startup if zero
+	OrphanCollectionInterval time.Duration
+	OrphanPolicy             OrphanPolicy
+
+	// Informer is the shared informer watching the replicated kind
+	Informer cache.SharedIndexInformer
+
+	// NamespaceWatcher is the namespace informer shared by all replicators
+	NamespaceWatcher *NamespaceWatcher
+}
+
+// CertificateIssuer references the cert-manager Issuer or ClusterIssuer of Certificates
+type CertificateIssuer struct {
+	Name string
+	Kind string
 }
 
 // UpdateFuncs stores the resource updater functions
@@ -37,116 +72,137 @@ type UpdateFuncs struct {
 	ReplicateDataFrom        func(source interface{}, target interface{}) error
 	ReplicateObjectTo        func(source interface{}, target *v1.Namespace) error
 	DeleteReplicatedResource func(target interface{}) error
+
+	// ReconcileSource is called once per reconcile of a source, if set, to sync resources that belong to the source
+	// as a whole rather than to one of its replicas
+	ReconcileSource func(sourceKey string) error
 }
 
 // GenericReplicator represents the top-level Replicator
 type GenericReplicator struct {
 	ReplicatorConfig
-	Store      cache.Store
-	Controller cache.Controller
-	Context    context.Context
+	Store   cache.Store
+	Context context.Context
+
+	// Queue is a rate limited work queue of "<namespace>/<name>" keys of resources
+	// that need to be replicated.
+	Queue workqueue.RateLimitingInterface
 
 	UpdateFuncs UpdateFuncs
 
-	// ReplicateToList is a set that caches the names of all resources that have a
-	// "replicate-to" annotation.
-	ReplicateToList map[string]struct{}
+	// Index records the replication rule of every source resource and the namespaces it has been replicated to.
+	// It is shared between the resource, namespace and worker goroutines.
+	Index *ReplicationIndex
+
+	// referenceSources are the replicators whose replicas reference resources of this kind
+	referenceSources []*referenceSource
+
+	// reconciled are called with the key of every resource after it has been reconciled
+	reconciled []func(key string)
+
+	// cacheSyncs are the additional informers the workers wait for before starting
+	cacheSyncs []cache.InformerSynced
 
-	// ReplicateToMatchingList is a set that caches the names of all resources
-	// that have a "replicate-to-matching" annotation.
-	ReplicateToMatchingList map[string]labels.Selector
+	// hostTemplates caches the template parsed from the HostTemplate annotation of every source by the source key
+	hostTemplates sync.Map
 }
 
-// NewGenericReplicator creates a new GenericReplicator
+// NewGenericReplicator creates a new GenericReplicator and registers its event handlers with the shared informers
 func NewGenericReplicator(ctx context.Context, config ReplicatorConfig) *GenericReplicator {
+	if config.Workers <= 0 {
+		config.Workers = DefaultWorkers
+	}
+
 	repl := GenericReplicator{
-		ReplicatorConfig:        config,
-		Context:                 ctx,
-		ReplicateToList:         make(map[string]struct{}),
-		ReplicateToMatchingList: make(map[string]labels.Selector),
+		ReplicatorConfig: config,
+		Context:          ctx,
+		Store:            config.Informer.GetStore(),
+		Queue:            workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), config.Kind),
+		Index:            NewReplicationIndex(),
 	}
 
-	store, controller := cache.NewInformer(
-		&cache.ListWatch{
-			ListFunc:  config.ListFunc,
-			WatchFunc: config.WatchFunc,
-		},
-		config.ObjType,
-		config.ResyncPeriod,
-		cache.ResourceEventHandlerFuncs{
-			AddFunc:    repl.ResourceAdded,
-			UpdateFunc: repl.ResourceUpdated,
-			DeleteFunc: repl.ResourceDeleted,
-		},
-	)
-
-	namespaceWatcher.OnNamespaceAdded(ctx, config.Client, config.ResyncPeriod, repl.NamespaceAdded)
-	namespaceWatcher.OnNamespaceUpdated(ctx, config.Client, config.ResyncPeriod, repl.NamespaceUpdated)
-
-	repl.Store = store
-	repl.Controller = controller
+	_, _ = config.Informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    repl.ResourceAdded,
+		UpdateFunc: repl.ResourceUpdated,
+		DeleteFunc: repl.ResourceDeleted,
+	})
+
+	config.NamespaceWatcher.OnNamespaceAdded(repl.NamespaceAdded)
+	config.NamespaceWatcher.OnNamespaceUpdated(repl.NamespaceUpdated)
 
 	return &repl
 }
 
+// AddCacheSync registers informers that the workers wait for before starting, such as informers of resources
+// that are read while replicating
+func (r *GenericReplicator) AddCacheSync(synced ...cache.InformerSynced) {
+	r.cacheSyncs = append(r.cacheSyncs, synced...)
+}
+
 // Synced reports whether or not the controller has been synced
 func (r *GenericReplicator) Synced() bool {
-	return r.Controller.HasSynced()
+	return r.Informer.HasSynced()
 }
 
-// Run starts the controller
-func (r *GenericReplicator) Run() {
-	log.WithField("kind", r.Kind).Infof("running %s controller", r.Kind)
-	r.Controller.Run(wait.NeverStop)
-}
+// Run waits for the shared informers to sync and starts the workers. Once the context is canceled, no new items are
+// picked up and Run blocks until in-flight replications have finished. Keys that are queued but not yet started are
+// dropped, their number is logged, and they are replicated again by the initial list once the controller is restarted.
+func (r *GenericReplicator) Run(ctx context.Context) {
+	logger := log.WithField("kind", r.Kind)
+	logger.Infof("running %s controller", r.Kind)
 
-// NamespaceAdded replicates resources with ReplicateTo and ReplicateToMatching
-// annotations into newly created namespaces.
-func (r *GenericReplicator) NamespaceAdded(ns *v1.Namespace) {
-	logger := log.WithField("kind", r.Kind).WithField("target", ns.Name)
-
-	for sourceKey := range r.ReplicateToList {
-		logger := logger.WithField("resource", sourceKey)
-		obj, err := r.ObjectFromStore(sourceKey)
-		if err != nil {
-			log.WithError(err).Error("error fetching object from store")
-			continue
-		}
+	cacheSyncs := append([]cache.InformerSynced{r.Informer.HasSynced, r.NamespaceWatcher.HasSynced}, r.cacheSyncs...)
+	for _, rs := range r.referenceSources {
+		cacheSyncs = append(cacheSyncs, rs.Synced)
+	}
 
-		objectMeta := MustGetObject(obj)
-		namespacePatterns, found := objectMeta.GetAnnotations()[ReplicateTo]
-		if found {
-			if err := r.replicateResourceToMatchingNamespaces(obj, namespacePatterns, []v1.Namespace{*ns}); err != nil {
-				logger.
-					WithError(err).
-					Errorf("Failed replicating the resource to the new namespace: %s", ns.Name)
-			}
-		}
+	if !cache.WaitForCacheSync(ctx.Done(), cacheSyncs...) {
+		logger.Errorf("failed to sync %s cache", r.Kind)
+		r.Queue.ShutDown()
+		return
 	}
 
-	namespaceLabels := labels.Set(ns.Labels)
-	for sourceKey, selector := range r.ReplicateToMatchingList {
-		logger := logger.WithField("resource", sourceKey)
+	logger.Infof("starting %d %s workers", r.Workers, r.Kind)
+	var wg sync.WaitGroup
+	for i := 0; i < r.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runWorker()
+		}()
+	}
 
-		obj, err := r.ObjectFromStore(sourceKey)
-		if err != nil {
-			log.WithError(err).Error("error fetching object from store")
-			continue
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if r.OrphanCollectionInterval > 0 {
+			wait.UntilWithContext(ctx, r.collectOrphans, r.OrphanCollectionInterval)
+		} else {
+			r.collectOrphans(ctx)
 		}
+	}()
 
-		if !selector.Matches(namespaceLabels) {
-			continue
-		}
+	<-ctx.Done()
+	logger.Infof("shutting down %s workers", r.Kind)
+	if dropped := r.Queue.Len(); dropped > 0 {
+		logger.Warnf("dropping %d queued %s keys that have not been started", dropped, r.Kind)
+	}
+	r.Queue.ShutDownWithDrain()
+	wg.Wait()
+	logger.Infof("stopped %s controller", r.Kind)
+}
 
-		if _, err := r.replicateResourceToNamespaces(obj, []v1.Namespace{*ns}); err != nil {
-			logger.WithError(err).Error("error while replicating object to namespace")
-		}
+// NamespaceAdded queues all resources whose replication rule selects the newly created namespace.
+func (r *GenericReplicator) NamespaceAdded(ns *v1.Namespace) {
+	for _, sourceKey := range r.Index.SelectingKeys(ns) {
+		r.Queue.Add(sourceKey)
 	}
 }
 
-// NamespaceUpdated checks if namespace's labels changed and deletes any 'replicate-to-matching' resources
-// the namespace no longer qualifies for. Then it attempts to replicate resources into the updated ns based
-// on the updated set of labels
+// NamespaceUpdated checks if namespace's labels or annotations changed. Changed labels queue all resources that either
+// select the namespace based on the updated set of labels or have previously been replicated into it, so that resources
+// the namespace no longer qualifies for are deleted. Changed annotations queue the resources replicated into it, so that
+// host templates reading the NamespaceAnnotations are rendered again.
 func (r *GenericReplicator) NamespaceUpdated(nsOld *v1.Namespace, nsNew *v1.Namespace) {
 	logger := log.WithField("kind", r.Kind).WithField("target", nsNew.Name)
 
@@ -154,161 +210,74 @@ func (r *GenericReplicator) NamespaceUpdated(nsOld *v1.Namespace, nsNew *v1.Name
 		return
 	}
 
-	if reflect.DeepEqual(nsNew.Labels, nsOld.Labels) {
-		logger.Debug("labels did not change")
+	labelsChanged := !reflect.DeepEqual(nsNew.Labels, nsOld.Labels)
+	annotationsChanged := !reflect.DeepEqual(nsNew.Annotations, nsOld.Annotations)
+	if !labelsChanged && !annotationsChanged {
+		logger.Debug("labels and annotations did not change")
 		return
 	}
 
-	logger.Infof("labels of namespace %s changed, attempting to delete %ses that no longer match", nsNew.Name, strings.TrimSuffix(r.Kind, "e"))
-	// delete any resources where namespace labels no longer match
-	var newLabelSet labels.Set = nsNew.Labels
-	var oldLabelSet labels.Set = nsOld.Labels
-	// check 'replicate-to-matching' resources against new labels
-	for sourceKey, selector := range r.ReplicateToMatchingList {
-		if selector.Matches(oldLabelSet) && !selector.Matches(newLabelSet) {
-			obj, err := r.ObjectFromStore(sourceKey)
-			if err != nil {
-				log.WithError(err).Error("error fetching object from store")
-				continue
-			}
-			// delete resource from the updated namespace
-			logger.Infof("removed %s %s from %s", r.Kind, sourceKey, nsNew.Name)
-			r.deleteResourceInNamespaces(obj, []v1.Namespace{*nsNew})
+	if labelsChanged {
+		logger.Infof("labels of namespace %s changed, attempting to reconcile %ses", nsNew.Name, strings.TrimSuffix(r.Kind, "e"))
+		for _, sourceKey := range r.Index.SelectingKeys(nsNew) {
+			r.Queue.Add(sourceKey)
 		}
+	} else {
+		logger.Infof("annotations of namespace %s changed, attempting to reconcile %ses", nsNew.Name, strings.TrimSuffix(r.Kind, "e"))
 	}
 
-	// replicate resources to updated ns
-	logger.Infof("labels of namespace %s changed, attempting to replicate %ses", nsNew.Name, strings.TrimSuffix(r.Kind, "e"))
-	r.NamespaceAdded(nsNew)
-
+	for _, sourceKey := range r.Index.TargetingKeys(nsNew.Name) {
+		r.Queue.Add(sourceKey)
+	}
 }
 
-// ResourceAdded checks resources with ReplicateTo or ReplicateFromAnnotation annotation
+// ResourceAdded queues resources with ReplicateTo or ReplicateToMatching annotations, resources referenced by other
+// replicated resources, as well as previously replicated resources that may have had their annotations removed.
+// Changes to replicated resources queue their source, so that drift from the source is corrected.
 func (r *GenericReplicator) ResourceAdded(obj interface{}) {
 	objectMeta := MustGetObject(obj)
-	sourceKey := MustGetKey(objectMeta)
-	logger := log.WithField("kind", r.Kind).WithField("resource", sourceKey)
 
 	if IsManagedBy(objectMeta) {
+		if !IgnoresDrift(objectMeta) {
+			r.enqueueSource(objectMeta)
+		}
 		return
 	}
 
-	annotations := objectMeta.GetAnnotations()
-
-	// Match resources with "replicate-to" annotation
-	if namespacePatterns, ok := annotations[ReplicateTo]; ok {
-		r.ReplicateToList[sourceKey] = struct{}{}
-
-		namespaces, _ := r.ListNamespaces()
-		if err := r.replicateResourceToMatchingNamespaces(obj, namespacePatterns, namespaces); err != nil {
-			logger.WithError(err).Errorf("could not replicate object to other namespaces")
-		}
-	} else {
-		delete(r.ReplicateToList, sourceKey)
+	key := MustGetKey(objectMeta)
+	if !HasReplicationAnnotations(objectMeta) && !r.Index.Has(key) && !r.isReferenced(key) {
+		return
 	}
 
-	// Match resources with "replicate-to-matching" annotations
-	if namespaceSelectorString, ok := annotations[ReplicateToMatching]; ok {
-		namespaceSelector, err := labels.Parse(namespaceSelectorString)
-		if err != nil {
-			delete(r.ReplicateToMatchingList, sourceKey)
-			logger.WithError(err).Error("failed to parse label selector")
-			return
-		}
-
-		r.ReplicateToMatchingList[sourceKey] = namespaceSelector
-		if err := r.replicateResourceToMatchingNamespacesByLabel(obj, namespaceSelector); err != nil {
-			logger.WithError(err).Error("error while replicating by label selector")
-		}
-	} else {
-		delete(r.ReplicateToMatchingList, sourceKey)
-	}
+	r.enqueue(obj)
 }
 
-// ResourceUpdated checks resources with ReplicateTo or ReplicateFromAnnotation annotation
+// ResourceUpdated queues updated resources, replicas that are no longer selected are deleted during reconciliation.
 func (r *GenericReplicator) ResourceUpdated(old interface{}, new interface{}) {
-	oldObj := MustGetObject(old)
-	newObj := MustGetObject(new)
-
-	if IsManagedBy(newObj) {
+	if IsManagedBy(MustGetObject(new)) && MustGetObject(old).GetResourceVersion() == MustGetObject(new).GetResourceVersion() {
 		return
 	}
 
-	oldAnnotations := oldObj.GetAnnotations()
-	newAnnotations := newObj.GetAnnotations()
-
-	if !reflect.DeepEqual(oldAnnotations, newAnnotations) {
-		r.deleteOldReplicateToResources(oldObj, newObj)
-		r.deleteOldReplicateToMatchingResources(oldObj, newObj)
-	}
-
 	r.ResourceAdded(new)
 }
 
-// ResourceDeleted watches for the deletion of resources
+// ResourceDeleted watches for the deletion of resources and queues them so that their replicas are deleted.
 func (r *GenericReplicator) ResourceDeleted(source interface{}) {
-	if IsManagedBy(MustGetObject(source)) {
-		return
-	}
-
-	sourceKey := MustGetKey(source)
-	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
-	logger.Debugf("Deleting dependents of %s %s", r.Kind, sourceKey)
-
-	r.resourceDeletedReplicateTo(source)
-
-	delete(r.ReplicateToList, sourceKey)
-}
-
-// replicateResourceToMatchingNamespaces replicates resources with ReplicateTo annotation
-func (r *GenericReplicator) replicateResourceToMatchingNamespaces(obj interface{}, patterns string, namespaceList []v1.Namespace) error {
-	cacheKey := MustGetKey(obj)
-
-	replicateTo := r.getFilteredNamespaces(MustGetObject(obj).GetNamespace(), patterns, namespaceList)
-
-	if replicated, err := r.replicateResourceToNamespaces(obj, replicateTo); err != nil {
-		return errors.Wrapf(err, "Replicated %s to %d out of %d namespaces",
-			cacheKey, len(replicated), len(replicateTo),
-		)
-	}
-
-	return nil
-}
-
-// replicateResourceToNamespaces will replicate the given object into target namespaces. It will return a list of
-// Namespaces it was successful in replicating into
-func (r *GenericReplicator) replicateResourceToNamespaces(obj interface{}, targets []v1.Namespace) (replicatedTo []v1.Namespace, err error) {
-	cacheKey := MustGetKey(obj)
-
-	for _, namespace := range targets {
-		if innerErr := r.UpdateFuncs.ReplicateObjectTo(obj, &namespace); innerErr != nil {
-			err = multierror.Append(err, errors.Wrapf(innerErr, "Failed to replicate %s %s -> %s: %v",
-				r.Kind, cacheKey, namespace.Name, innerErr,
-			))
-		} else {
-			replicatedTo = append(replicatedTo, namespace)
-		}
+	if tombstone, ok := source.(cache.DeletedFinalStateUnknown); ok {
+		source = tombstone.Obj
 	}
 
-	return
-}
-
-// replicateResourceToMatchingNamespacesByLabel replicates to resources in namespaces with selected labels
-func (r *GenericReplicator) replicateResourceToMatchingNamespacesByLabel(obj interface{}, selector labels.Selector) error {
-	cacheKey := MustGetKey(obj)
-
-	namespaces, err := r.ListNamespaces(metav1.ListOptions{LabelSelector: selector.String()})
-	if err != nil {
-		return errors.Wrap(err, "error while listing namespaces by selector")
+	if objectMeta := MustGetObject(source); IsManagedBy(objectMeta) {
+		// replicas that are deleted while still selected are recreated
+		r.enqueueSource(objectMeta)
+		return
 	}
 
-	if replicated, err := r.replicateResourceToNamespaces(obj, namespaces); err != nil {
-		return errors.Wrapf(err, "Replicated %s to %d out of %d namespaces",
-			cacheKey, len(replicated), len(namespaces),
-		)
+	sourceKey := MustGetKey(source)
+	if r.Index.Has(sourceKey) {
+		log.WithField("kind", r.Kind).WithField("source", sourceKey).Debugf("Deleting dependents of %s %s", r.Kind, sourceKey)
+		r.Queue.Add(sourceKey)
 	}
-
-	return nil
 }
 
 // ObjectFromStore gets object from store cache
@@ -325,223 +294,36 @@ func (r *GenericReplicator) ObjectFromStore(key string) (interface{}, error) {
 	return obj, nil
 }
 
-// ResourceDeletedReplicateTo deletes dependent resources that were replicated to
-func (r *GenericReplicator) resourceDeletedReplicateTo(source interface{}) {
-	sourceKey := MustGetKey(source)
-	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
-
-	if err := r.deleteFilteredNamespaceResources(source); err != nil {
-		logger.WithError(err).Errorf("Could not delete resources from filtered namespaces")
-	}
-
-	if err := r.deletedLabelSelectedNamespaceResources(source); err != nil {
-		logger.WithError(err).Errorf("Could not delete resources from label selected namespaces")
-	}
-}
-
-// DeleteFilteredNamespaceResources deletes resources from a filtered namespace list
-func (r *GenericReplicator) deleteFilteredNamespaceResources(source interface{}) error {
-	objMeta := MustGetObject(source)
-
-	patterns, replicateTo := objMeta.GetAnnotations()[ReplicateTo]
-	if !replicateTo {
-		return nil
-	}
-
-	namespaces, err := r.ListFilteredNamespaces(objMeta.GetNamespace(), patterns)
-	if err != nil {
-		return errors.Wrapf(err, "Failed to list namespaces: %v", err)
-	}
-
-	r.deleteResourceInNamespaces(source, namespaces)
-	return nil
-}
-
-// DeletedLabelSelectedNamespaceResources deletes resources from label selected namespaces
-func (r *GenericReplicator) deletedLabelSelectedNamespaceResources(source interface{}) error {
-	objMeta := MustGetObject(source)
-
-	namespaceSelectorString, replicateToMatching := objMeta.GetAnnotations()[ReplicateToMatching]
-	if !replicateToMatching {
-		return nil
-	}
-
-	namespaces, err := r.ListLabelSelectedNamespaces(namespaceSelectorString)
-	if err != nil {
-		return err
-	}
-
-	r.deleteResourceInNamespaces(source, namespaces)
-	return nil
-}
-
-// DeleteResourceInNamespaces deletes resources in a list of namespaces acquired by evaluating namespace labels
-func (r *GenericReplicator) deleteResourceInNamespaces(source interface{}, namespaces []v1.Namespace) {
-	for _, namespace := range namespaces {
-		r.deleteResource(namespace, source)
-	}
-}
-
-// DeleteResource deletes a single resource from the provided namespace
-func (r *GenericReplicator) deleteResource(namespace v1.Namespace, source interface{}) {
-	sourceKey := MustGetKey(source)
-	logger := log.WithField("kind", r.Kind).WithField("source", sourceKey)
-	objMeta := MustGetObject(source)
-
-	if namespace.Name == objMeta.GetNamespace() {
-		return
-	}
-	targetLocation := fmt.Sprintf("%s/%s", namespace.Name, objMeta.GetName())
-	targetResource, err := r.ObjectFromStore(targetLocation)
-	if err != nil {
-		logger.WithError(err).Errorf("Could not get objectMeta %s: %v", targetLocation, err)
-		return
-	}
-
-	logger.Infof("Deleting %s: %s", r.Kind, targetLocation)
-	if err := r.UpdateFuncs.DeleteReplicatedResource(targetResource); err != nil {
-		logger.WithError(err).Errorf("Could not delete resource %s: %v", targetLocation, err)
-	}
-}
-
-func (r *GenericReplicator) deleteOldReplicateToResources(oldObj, newObj metav1.Object) {
-	logger := log.WithField("source", MustGetKey(newObj)).WithField("kind", r.Kind)
-
-	oldPatterns, oldReplicateTo := oldObj.GetAnnotations()[ReplicateTo]
-	if !oldReplicateTo {
-		logger.Debug("old resource does not have a replicate-to annotation")
-		return
-	}
-
-	oldNamespaces, err := r.ListFilteredNamespaces(oldObj.GetNamespace(), oldPatterns)
-	if err != nil || len(oldNamespaces) == 0 {
-		logger.Debug("old resource does not replicate to any current namespaces")
-		return
-	}
-
-	newPatterns, newReplicateTo := newObj.GetAnnotations()[ReplicateTo]
-	if !newReplicateTo {
-		logger.Debug("new resource does not have a replicate-to annotation")
-		r.deleteResourceInNamespaces(oldObj, oldNamespaces)
-		return
-	}
-
-	newNamespaces, err := r.ListFilteredNamespaces(newObj.GetNamespace(), newPatterns)
-	if err != nil || len(newNamespaces) == 0 {
-		logger.Debug("new resource not replicate to any current namespaces")
-		r.deleteResourceInNamespaces(oldObj, oldNamespaces)
-		return
-	}
-
-	var removedNamespaces []v1.Namespace
-Outer:
-	for _, oldNamespace := range oldNamespaces {
-		for _, newNamespace := range newNamespaces {
-			if oldNamespace.Name == newNamespace.Name {
-				continue Outer
-			}
-		}
-		removedNamespaces = append(removedNamespaces, oldNamespace)
-	}
-
-	logger.Debugf("deleting %d resources", len(removedNamespaces))
-	r.deleteResourceInNamespaces(oldObj, removedNamespaces)
-}
-
-func (r *GenericReplicator) deleteOldReplicateToMatchingResources(oldObj, newObj metav1.Object) {
-	logger := log.WithField("source", MustGetKey(newObj)).WithField("kind", r.Kind)
-
-	oldLabelSelector, oldReplicateToMatching := oldObj.GetAnnotations()[ReplicateToMatching]
-	if !oldReplicateToMatching {
-		logger.Debug("old resource does not have a replicate-to-matching annotation")
-		return
-	}
-
-	oldNamespaces, err := r.ListLabelSelectedNamespaces(oldLabelSelector)
-	if err != nil || len(oldNamespaces) == 0 {
-		logger.Debug("old resource does not replicate to any current label selected namespaces")
-		return
-	}
-
-	newLabelSelector, newReplicateToMatching := newObj.GetAnnotations()[ReplicateToMatching]
-	if !newReplicateToMatching {
-		logger.Debug("new resource does not have a replicate-to-matching annotation")
-		r.deleteResourceInNamespaces(oldObj, oldNamespaces)
-		return
-	}
-
-	newNamespaces, err := r.ListLabelSelectedNamespaces(newLabelSelector)
-	if err != nil || len(newNamespaces) == 0 {
-		logger.Debug("new resource not replicate to any current label selected namespaces")
-		r.deleteResourceInNamespaces(oldObj, oldNamespaces)
-		return
-	}
-
-	var removedNamespaces []v1.Namespace
-Outer:
-	for _, oldNamespace := range oldNamespaces {
-		for _, newNamespace := range newNamespaces {
-			if oldNamespace.Name == newNamespace.Name {
-				continue Outer
-			}
-		}
-		removedNamespaces = append(removedNamespaces, oldNamespace)
-	}
-
-	logger.Debugf("deleting %d resources", len(removedNamespaces))
-	r.deleteResourceInNamespaces(oldObj, removedNamespaces)
+// ListNamespaces lists all namespaces from the shared namespace cache
+func (r *GenericReplicator) ListNamespaces() ([]*v1.Namespace, error) {
+	return r.ListLabelSelectedNamespaces(labels.Everything())
 }
 
-// ListNamespaces is a simple wrapper for listing namespaces
-func (r *GenericReplicator) ListNamespaces(listOptions ...metav1.ListOptions) ([]v1.Namespace, error) {
-	if len(listOptions) == 0 {
-		listOptions = append(listOptions, metav1.ListOptions{})
-	}
-	namespaceList, err := r.Client.CoreV1().Namespaces().List(r.Context, listOptions[0])
-	return namespaceList.Items, err
+// ListLabelSelectedNamespaces lists the namespaces that meet the label selector from the shared namespace cache
+func (r *GenericReplicator) ListLabelSelectedNamespaces(selector labels.Selector) ([]*v1.Namespace, error) {
+	return r.NamespaceWatcher.Lister.List(selector)
 }
 
-// ListLabelSelectedNamespaces retrieves list of namespaces that meet the label selector
-func (r *GenericReplicator) ListLabelSelectedNamespaces(namespaceSelectorString string) ([]v1.Namespace, error) {
-	namespaceSelector, err := labels.Parse(namespaceSelectorString)
-	if err != nil {
-		return nil, errors.Wrapf(err, "Failed parse namespace selector: %v", err)
-	}
-
-	namespaces, err := r.ListNamespaces(metav1.ListOptions{LabelSelector: namespaceSelector.String()})
-	if err != nil {
-		return nil, errors.Wrapf(err, "Failed to list namespaces: %v", err)
+// ListRuleNamespaces lists the namespaces that are selected by the provided replication rule
+func (r *GenericReplicator) ListRuleNamespaces(rule ReplicationRule) (selected []*v1.Namespace, err error) {
+	var namespaces []*v1.Namespace
+	if len(rule.Patterns) == 0 && rule.Selector != nil {
+		namespaces, err = r.ListLabelSelectedNamespaces(rule.Selector)
+	} else {
+		namespaces, err = r.ListNamespaces()
 	}
 
-	return namespaces, nil
-}
-
-// ListFilteredNamespaces retrieve list of namespaces that match the provided patterns
-func (r *GenericReplicator) ListFilteredNamespaces(current string, patterns string) ([]v1.Namespace, error) {
-	namespaces, err := r.ListNamespaces()
 	if err != nil {
 		return nil, err
 	}
 
-	return r.getFilteredNamespaces(current, patterns, namespaces), nil
-}
-
-// getFilteredNamespaces will check the provided filters return a list of namespace that fit the filter conditions
-func (r *GenericReplicator) getFilteredNamespaces(current string, patterns string, namespaces []v1.Namespace) []v1.Namespace {
-
-	filtered := make([]v1.Namespace, 0)
 	for _, namespace := range namespaces {
-		if namespace.Name == current {
-			continue
-		}
-		for _, ns := range StringToPatternList(patterns) {
-			if matched := ns.MatchString(namespace.Name); matched {
-				filtered = append(filtered, namespace)
-				break
-			}
+		if rule.Matches(namespace) {
+			selected = append(selected, namespace)
 		}
 	}
-	return filtered
+
+	return selected, nil
 }
 
 // HasDefaultIngressHostname returns a boolean value determining whether or not a default Ingress hostname was provided.
@@ -0,0 +1,258 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestReplicator(ctx context.Context, client kubernetes.Interface) (*GenericReplicator, informers.SharedInformerFactory) {
+	factory := informers.NewSharedInformerFactory(client, 0)
+
+	repl := NewGenericReplicator(ctx, ReplicatorConfig{
+		Kind:             "Service",
+		Client:           client,
+		Informer:         factory.Core().V1().Services().Informer(),
+		NamespaceWatcher: NewNamespaceWatcher(factory.Core().V1().Namespaces()),
+	})
+
+	repl.UpdateFuncs = UpdateFuncs{
+		ReplicateObjectTo: func(source interface{}, target *v1.Namespace) error {
+			replica := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:        MustGetObject(source).GetName(),
+				Namespace:   target.Name,
+				Labels:      map[string]string{ManagedByLabelKey: ManagedByLabelValue},
+				Annotations: map[string]string{ReplicatedFromAnnotation: MustGetKey(source)},
+			}}
+
+			_, err := client.CoreV1().Services(target.Name).Create(ctx, replica, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				return nil
+			}
+			return err
+		},
+		DeleteReplicatedResource: func(target interface{}) error {
+			replica := MustGetObject(target)
+			return client.CoreV1().Services(replica.GetNamespace()).Delete(ctx, replica.GetName(), metav1.DeleteOptions{})
+		},
+	}
+
+	return repl, factory
+}
+
+// replicaKeys lists the keys of all managed resources in the replicator's cache
+func replicaKeys(repl *GenericReplicator) []string {
+	keys := make([]string, 0)
+	for _, obj := range repl.Store.List() {
+		if IsManagedBy(MustGetObject(obj)) {
+			keys = append(keys, MustGetKey(obj))
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func labelNamespace(t *testing.T, client kubernetes.Interface, name string, labels map[string]string) {
+	namespace, err := client.CoreV1().Namespaces().Get(context.Background(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	namespace.Labels = labels
+	_, err = client.CoreV1().Namespaces().Update(context.Background(), namespace, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+}
+
+func Test_GenericReplicator_ConcurrentEvents(t *testing.T) {
+	ctx := context.Background()
+
+	var namespaces []runtime.Object
+	for i := 0; i < 5; i++ {
+		namespaces = append(namespaces,
+			testNamespace(fmt.Sprintf("feature-%d", i), nil),
+			testNamespace(fmt.Sprintf("branch-%d", i), nil),
+		)
+	}
+
+	client := fake.NewSimpleClientset(namespaces...)
+	repl, factory := newTestReplicator(ctx, client)
+	defer repl.Queue.ShutDown()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	for i := 0; i < 4; i++ {
+		go repl.runWorker()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// resource and namespace events are delivered by the shared informers
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("service-%d", i),
+				Namespace: "default",
+				Annotations: map[string]string{
+					ReplicateTo:         "feature-.*",
+					ReplicateToMatching: "feature-branch",
+				},
+			}}
+			_, err := client.CoreV1().Services(service.Namespace).Create(ctx, service, metav1.CreateOptions{})
+			assert.NoError(t, err)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			labelNamespace(t, client, fmt.Sprintf("branch-%d", i), map[string]string{"feature-branch": "true"})
+		}
+	}()
+
+	wg.Wait()
+
+	var expected []string
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 5; j++ {
+			expected = append(expected,
+				fmt.Sprintf("feature-%d/service-%d", j, i),
+				fmt.Sprintf("branch-%d/service-%d", j, i),
+			)
+		}
+	}
+	sort.Strings(expected)
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(expected, replicaKeys(repl))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// remove the label from a namespace and delete a source concurrently
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		labelNamespace(t, client, "branch-0", nil)
+	}()
+	go func() {
+		defer wg.Done()
+		assert.NoError(t, client.CoreV1().Services("default").Delete(ctx, "service-0", metav1.DeleteOptions{}))
+	}()
+	wg.Wait()
+
+	var remaining []string
+	for _, key := range expected {
+		if strings.HasPrefix(key, "branch-0/") || strings.HasSuffix(key, "/service-0") {
+			continue
+		}
+		remaining = append(remaining, key)
+	}
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(remaining, replicaKeys(repl))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.False(t, repl.Index.Has("default/service-0"))
+	assert.NotContains(t, repl.Index.Targets("default/service-1"), "branch-0")
+}
+
+func Test_GenericReplicator_RunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	client := fake.NewSimpleClientset(testNamespace("feature-0", nil))
+	repl, factory := newTestReplicator(context.Background(), client)
+	factory.Start(ctx.Done())
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		repl.Run(ctx)
+	}()
+
+	_, err := client.CoreV1().Services("default").Create(ctx, &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "service-0",
+		Namespace:   "default",
+		Annotations: map[string]string{ReplicateTo: "feature-.*"},
+	}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"feature-0/service-0"}, replicaKeys(repl))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("replicator did not stop after the context was canceled")
+	}
+
+	assert.True(t, repl.Queue.ShuttingDown())
+}
+
+func Test_GenericReplicator_RecreatesDeletedReplica(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset(testNamespace("feature-0", nil))
+	repl, factory := newTestReplicator(ctx, client)
+	factory.Start(ctx.Done())
+	go repl.Run(ctx)
+
+	_, err := client.CoreV1().Services("default").Create(ctx, &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        "service-0",
+		Namespace:   "default",
+		Annotations: map[string]string{ReplicateTo: "feature-.*"},
+	}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"feature-0/service-0"}, replicaKeys(repl))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, client.CoreV1().Services("feature-0").Delete(ctx, "service-0", metav1.DeleteOptions{}))
+
+	assert.Eventually(t, func() bool {
+		_, err := client.CoreV1().Services("feature-0").Get(ctx, "service-0", metav1.GetOptions{})
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_GenericReplicator_NamespaceUpdated(t *testing.T) {
+	repl, _ := newTestReplicator(context.Background(), fake.NewSimpleClientset())
+	defer repl.Queue.ShutDown()
+
+	rule, _ := NewReplicationRule(&metav1.ObjectMeta{Namespace: "default", Annotations: map[string]string{ReplicateTo: "feature-.*"}})
+	repl.Index.SetRule("default/nginx", rule)
+	repl.Index.AddTarget("default/nginx", "feature-a")
+
+	old := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-a", Annotations: map[string]string{"owner": "team-a"}}}
+
+	repl.NamespaceUpdated(old, old.DeepCopy())
+	assert.Equal(t, 0, repl.Queue.Len())
+
+	// host templates may read the annotations of the namespace
+	updated := old.DeepCopy()
+	updated.Annotations["owner"] = "team-b"
+	repl.NamespaceUpdated(old, updated)
+	assert.Equal(t, 1, repl.Queue.Len())
+
+	key, _ := repl.Queue.Get()
+	assert.Equal(t, "default/nginx", key)
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ComputeHash hashes the prepared resource, ignoring the annotations that change on every replication
+func ComputeHash(prepared metav1.Object) (string, error) {
+	annotations := prepared.GetAnnotations()
+	defer prepared.SetAnnotations(annotations)
+
+	hashed := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		if key == ReplicatedAtAnnotation || key == ReplicatedHashAnnotation {
+			continue
+		}
+		hashed[key] = value
+	}
+	prepared.SetAnnotations(hashed)
+
+	data, err := json.Marshal(prepared)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// MustSetReplicatedHash adds the ReplicatedHashAnnotation to the prepared resource and panics if it can not be computed
+func MustSetReplicatedHash(prepared metav1.Object) {
+	hash, err := ComputeHash(prepared)
+	if err != nil {
+		panic(err)
+	}
+
+	annotations := prepared.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[ReplicatedHashAnnotation] = hash
+	prepared.SetAnnotations(annotations)
+}
+
+// ReplicatedHashEqual checks whether the target was last replicated from the same prepared resource
+func ReplicatedHashEqual(prepared metav1.Object, target metav1.Object) bool {
+	targetHash, ok := target.GetAnnotations()[ReplicatedHashAnnotation]
+
+	return ok && targetHash == prepared.GetAnnotations()[ReplicatedHashAnnotation]
+}
@@ -0,0 +1,51 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testPreparedService(externalName string, replicatedAt string) *v1.Service {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "nginx",
+			Namespace: "feature-0",
+			Annotations: map[string]string{
+				ReplicatedFromAnnotation: "default/nginx",
+				ReplicatedAtAnnotation:   replicatedAt,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Type:         v1.ServiceTypeExternalName,
+			ExternalName: externalName,
+		},
+	}
+
+	MustSetReplicatedHash(service)
+	return service
+}
+
+func Test_MustSetReplicatedHash(t *testing.T) {
+	service := testPreparedService("nginx.default.svc.cluster.local", "2023-01-01T00:00:00Z")
+
+	assert.Len(t, service.Annotations[ReplicatedHashAnnotation], 64)
+	assert.Equal(t, "2023-01-01T00:00:00Z", service.Annotations[ReplicatedAtAnnotation])
+
+	// setting the hash again must not change it
+	hash := service.Annotations[ReplicatedHashAnnotation]
+	MustSetReplicatedHash(service)
+	assert.Equal(t, hash, service.Annotations[ReplicatedHashAnnotation])
+}
+
+func Test_ReplicatedHashEqual(t *testing.T) {
+	target := testPreparedService("nginx.default.svc.cluster.local", "2023-01-01T00:00:00Z")
+
+	assert.True(t, ReplicatedHashEqual(testPreparedService("nginx.default.svc.cluster.local", "2023-01-02T00:00:00Z"), target))
+	assert.False(t, ReplicatedHashEqual(testPreparedService("nginx.default.traefik.mesh", "2023-01-01T00:00:00Z"), target))
+
+	delete(target.Annotations, ReplicatedHashAnnotation)
+	assert.False(t, ReplicatedHashEqual(testPreparedService("nginx.default.svc.cluster.local", "2023-01-01T00:00:00Z"), target))
+}
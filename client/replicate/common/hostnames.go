@@ -0,0 +1,159 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultMaxHostLabelLength is the maximum length of a DNS label
+const DefaultMaxHostLabelLength = validation.DNS1123LabelMaxLength
+
+// MinMaxHostLabelLength is the shortest configurable label length that leaves room for a prefix next to the hash
+const MinMaxHostLabelLength = 2 * hostLabelHashLength
+
+// hostLabelHashLength is the number of hex characters of the hash appended to shortened labels
+const hostLabelHashLength = 8
+
+// ShortenHost makes every label of the host a valid DNS label of at most maxLabelLength characters. Labels are
+// lowercased and other characters than alphanumerics and '-' are replaced with '-'. Labels that had to be sanitized or
+// are too long are truncated if needed and suffixed with a short hash of the original label, so that the same host is
+// always shortened the same way and different labels that sanitize to the same or a shared prefix stay distinct.
+// Wildcard and empty labels are kept as-is.
+func ShortenHost(host string, maxLabelLength int) string {
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		labels[i] = shortenLabel(label, maxLabelLength)
+	}
+
+	return strings.Join(labels, ".")
+}
+
+func shortenLabel(label string, maxLength int) string {
+	if len(label) == 0 || label == "*" {
+		return label
+	}
+
+	sanitized := sanitizeLabel(label)
+	if sanitized == label && len(sanitized) <= maxLength {
+		return label
+	}
+
+	sum := sha256.Sum256([]byte(label))
+	hash := hex.EncodeToString(sum[:])[:hostLabelHashLength]
+
+	prefix := sanitized
+	if len(prefix) > maxLength-hostLabelHashLength-1 {
+		prefix = strings.TrimRight(prefix[:maxLength-hostLabelHashLength-1], "-")
+	}
+	if len(prefix) == 0 {
+		return hash
+	}
+
+	return prefix + "-" + hash
+}
+
+// sanitizeLabel lowercases the label and replaces characters that are invalid in DNS labels with '-'
+func sanitizeLabel(label string) string {
+	var builder strings.Builder
+	for _, c := range strings.ToLower(label) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' {
+			builder.WriteRune(c)
+		} else {
+			builder.WriteByte('-')
+		}
+	}
+
+	return strings.Trim(builder.String(), "-")
+}
+
+// ValidateHost checks that the host is a DNS subdomain whose labels are valid DNS labels, with an optional leading
+// wildcard label
+func ValidateHost(host string) error {
+	var messages []string
+	if len(host) > validation.DNS1123SubdomainMaxLength {
+		messages = append(messages, validation.MaxLenError(validation.DNS1123SubdomainMaxLength))
+	}
+
+	for i, label := range strings.Split(host, ".") {
+		if i == 0 && label == "*" {
+			continue
+		}
+		for _, message := range validation.IsDNS1123Label(label) {
+			messages = append(messages, fmt.Sprintf("label %q: %s", label, message))
+		}
+	}
+
+	if len(messages) > 0 {
+		return errors.Errorf("invalid host %s: %s", host, strings.Join(messages, "; "))
+	}
+
+	return nil
+}
+
+// HostMapping records the hosts prepared for a replica: the generated hosts that were sanitized or shortened to
+// valid hosts, and the errors of the prepared hosts that are still invalid
+type HostMapping struct {
+	Shortened map[string]string
+	Invalid   []error
+}
+
+// NewHostMapping creates an empty HostMapping
+func NewHostMapping() *HostMapping {
+	return &HostMapping{Shortened: make(map[string]string)}
+}
+
+// String lists the shortened hosts as sorted "<generated>=<shortened>" pairs
+func (m *HostMapping) String() string {
+	pairs := make([]string, 0, len(m.Shortened))
+	for generated, shortened := range m.Shortened {
+		pairs = append(pairs, generated+"="+shortened)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ", ")
+}
+
+// SetHostMapping adds the ShortenedHostsAnnotation to the prepared resource if any of its hosts were shortened, and the
+// InvalidHostsAnnotation if any of its hosts are invalid. Annotations copied from the source are removed otherwise.
+func SetHostMapping(prepared metav1.Object, mapping *HostMapping) {
+	annotations := prepared.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	delete(annotations, ShortenedHostsAnnotation)
+	if len(mapping.Shortened) > 0 {
+		annotations[ShortenedHostsAnnotation] = mapping.String()
+	}
+
+	delete(annotations, InvalidHostsAnnotation)
+	if len(mapping.Invalid) > 0 {
+		messages := make([]string, 0, len(mapping.Invalid))
+		for _, err := range mapping.Invalid {
+			messages = append(messages, err.Error())
+		}
+		annotations[InvalidHostsAnnotation] = strings.Join(messages, ", ")
+	}
+
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	prepared.SetAnnotations(annotations)
+}
+
+// InvalidHosts is the error of the invalid hosts recorded in the InvalidHostsAnnotation of a prepared resource, which
+// must not be written to the target namespace
+func InvalidHosts(prepared metav1.Object) error {
+	if message, ok := prepared.GetAnnotations()[InvalidHostsAnnotation]; ok {
+		return errors.Errorf("prepared %s has invalid hosts: %s", MustGetKey(prepared), message)
+	}
+
+	return nil
+}
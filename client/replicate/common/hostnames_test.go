@@ -0,0 +1,112 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const longLabel = "feature-a-very-long-branch-name-that-exceeds-the-dns-label-limit--api"
+
+func Test_ShortenHost(t *testing.T) {
+	cases := map[string]string{
+		"feature-a.example.com":    "feature-a.example.com",
+		"*.feature-a.example.com":  "*.feature-a.example.com",
+		"Feature_A.example.com":    "feature-a-8eb49d14.example.com",
+		"-feature-a-.example.com":  "feature-a-e0e9afe7.example.com",
+		"___.example.com":          "bda25155.example.com",
+		longLabel + ".example.com": "feature-a-very-long-branch-name-that-exceeds-the-dns-l-61e6ac81.example.com",
+		"feature-a..example.com":   "feature-a..example.com",
+	}
+
+	for host, expected := range cases {
+		shortened := ShortenHost(host, DefaultMaxHostLabelLength)
+		assert.Equal(t, expected, shortened, host)
+	}
+
+	assert.Equal(t, "feature-61e6ac81.example.com", ShortenHost(longLabel+".example.com", MinMaxHostLabelLength))
+}
+
+func Test_ShortenHost_Valid(t *testing.T) {
+	for _, maxLength := range []int{MinMaxHostLabelLength, 20, DefaultMaxHostLabelLength} {
+		for _, host := range []string{longLabel + ".example.com", "Feature_Branch.example.com", "a-" + longLabel + "-b.example.com"} {
+			shortened := ShortenHost(host, maxLength)
+			assert.NoError(t, ValidateHost(shortened), host)
+			assert.Equal(t, shortened, ShortenHost(host, maxLength), "shortening is deterministic")
+
+			for _, label := range NewHost(shortened).Labels {
+				assert.LessOrEqual(t, len(label), maxLength, shortened)
+			}
+		}
+	}
+
+	// labels sharing a prefix stay distinct
+	assert.NotEqual(t, ShortenHost(longLabel+"-a.example.com", 20), ShortenHost(longLabel+"-b.example.com", 20))
+}
+
+func Test_ShortenHost_Distinct(t *testing.T) {
+	// labels that sanitize to the same label stay distinct from each other and from the sanitized label
+	hosts := map[string]string{}
+	for _, host := range []string{"feature-x.example.com", "feature_x.example.com", "Feature-X.example.com", "feature+x.example.com"} {
+		shortened := ShortenHost(host, DefaultMaxHostLabelLength)
+		assert.NoError(t, ValidateHost(shortened), host)
+		assert.NotContains(t, hosts, shortened, host)
+		hosts[shortened] = host
+	}
+}
+
+func Test_ValidateHost(t *testing.T) {
+	assert.NoError(t, ValidateHost("feature-a.example.com"))
+	assert.NoError(t, ValidateHost("*.feature-a.example.com"))
+
+	assert.ErrorContains(t, ValidateHost(longLabel+".example.com"), "must be no more than 63 characters")
+	assert.ErrorContains(t, ValidateHost("feature_a.example.com"), `label "feature_a"`)
+	assert.ErrorContains(t, ValidateHost("feature-a..example.com"), `label ""`)
+	assert.ErrorContains(t, ValidateHost("feature-a.*.example.com"), `label "*"`)
+}
+
+func Test_SetHostMapping(t *testing.T) {
+	prepared := &v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{ShortenedHostsAnnotation: "stale"}}}
+
+	SetHostMapping(prepared, NewHostMapping())
+	assert.NotContains(t, prepared.Annotations, ShortenedHostsAnnotation)
+	assert.NoError(t, InvalidHosts(prepared))
+
+	SetHostMapping(prepared, &HostMapping{
+		Shortened: map[string]string{"b.example.com": "b2.example.com", "a.example.com": "a2.example.com"},
+		Invalid:   []error{ValidateHost("feature-a..example.com")},
+	})
+	assert.Equal(t, "a.example.com=a2.example.com, b.example.com=b2.example.com", prepared.Annotations[ShortenedHostsAnnotation])
+	assert.ErrorContains(t, InvalidHosts(prepared), "invalid host feature-a..example.com")
+}
+
+func Test_GenericReplicator_PrepareMappedTLD(t *testing.T) {
+	repl := newHostTemplateReplicator(t, "{{ .Namespace }}--{{ .Host.FirstLabel }}.preview.example.com")
+	namespace := longLabel[:len(longLabel)-len("--api")]
+
+	mapping := NewHostMapping()
+	prepared := repl.PrepareMappedTLD(namespace, hostTemplateSource(""), "api.example.com", mapping)
+	assert.Equal(t, "feature-a-very-long-branch-name-that-exceeds-the-dns-l-61e6ac81.preview.example.com", prepared)
+	assert.Equal(t, map[string]string{longLabel + ".preview.example.com": prepared}, mapping.Shortened)
+
+	// hosts that are valid are not recorded
+	prepared = repl.PrepareMappedTLD("feature-a", hostTemplateSource(""), "api.example.com", mapping)
+	assert.Equal(t, "feature-a--api.preview.example.com", prepared)
+	assert.Len(t, mapping.Shortened, 1)
+
+	// hosts that only had to be sanitized are recorded as well
+	prepared = repl.PrepareMappedTLD("feature_a", hostTemplateSource(""), "api.example.com", mapping)
+	assert.Equal(t, "feature-a--api-6c64a31d.preview.example.com", prepared)
+	assert.Equal(t, prepared, mapping.Shortened["feature_a--api.preview.example.com"])
+	assert.Empty(t, mapping.Invalid)
+
+	// hosts that are still invalid are recorded with their error
+	prepared = repl.PrepareMappedTLD("feature-a", hostTemplateSource("{{ .Namespace }}..example.com"), "api.example.com", mapping)
+	assert.Equal(t, "feature-a..example.com", prepared)
+	assert.Len(t, mapping.Invalid, 1)
+
+	repl.MaxHostLabelLength = MinMaxHostLabelLength
+	assert.Equal(t, "feature-61e6ac81.preview.example.com", repl.PrepareTLD(namespace, hostTemplateSource(""), "api.example.com"))
+}
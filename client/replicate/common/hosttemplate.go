@@ -0,0 +1,166 @@
+package common
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HostTemplateData is the data that host templates are executed with, such as
+// "{{ .Namespace }}--{{ .Host.FirstLabel }}.preview.example.com"
+type HostTemplateData struct {
+	// Namespace is the name of the namespace the host is prepared for
+	Namespace            string
+	NamespaceLabels      map[string]string
+	NamespaceAnnotations map[string]string
+
+	// Host is the host of the source, or the top-level domain or default hostname that replaces it
+	Host Host
+}
+
+// Host is a hostname whose DNS labels can be used in host templates
+type Host struct {
+	Name   string
+	Labels []string
+}
+
+// NewHost splits a hostname into its labels
+func NewHost(name string) Host {
+	return Host{Name: name, Labels: strings.Split(name, ".")}
+}
+
+// String is the full hostname, so that {{ .Host }} prints the original host
+func (h Host) String() string {
+	return h.Name
+}
+
+// FirstLabel is the leftmost label of the host, such as "api" of "api.example.com"
+func (h Host) FirstLabel() string {
+	return h.Labels[0]
+}
+
+// Domain is the host without its first label, such as "example.com" of "api.example.com"
+func (h Host) Domain() string {
+	return strings.Join(h.Labels[1:], ".")
+}
+
+// ParseHostTemplate parses a host template. Missing map keys accessed as fields, such as
+// {{ .NamespaceLabels.team }}, fail the execution instead of producing an empty label.
+func ParseHostTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("host").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid host template")
+	}
+
+	return tmpl, nil
+}
+
+// ExecuteHostTemplate executes a host template, trimming the whitespace surrounding the produced host
+func ExecuteHostTemplate(tmpl *template.Template, data HostTemplateData) (string, error) {
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", errors.Wrap(err, "failed to execute host template")
+	}
+
+	host := strings.TrimSpace(builder.String())
+	if len(host) == 0 {
+		return "", errors.Errorf("host template produced an empty host for %s", data.Host)
+	}
+
+	return host, nil
+}
+
+// PrepareTLD prepares a host for the namespace with the HostTemplate annotation of the source, or else the configured
+// HostTemplate. Without a template, or if the template fails, the first label of the host is replaced with the
+// namespace like the PrepareTLD function. Labels of the prepared host that are not valid DNS labels are shortened.
+func (r *GenericReplicator) PrepareTLD(namespace string, source metav1.Object, host string) string {
+	return r.PrepareMappedTLD(namespace, source, host, nil)
+}
+
+// PrepareMappedTLD prepares a host like PrepareTLD and records it in the mapping, if set, when it had to be shortened
+// or is invalid. Without a mapping, invalid hosts are logged with a warning.
+func (r *GenericReplicator) PrepareMappedTLD(namespace string, source metav1.Object, host string, mapping *HostMapping) string {
+	generated := r.generateHost(namespace, source, host)
+
+	maxLabelLength := r.MaxHostLabelLength
+	if maxLabelLength <= 0 {
+		maxLabelLength = DefaultMaxHostLabelLength
+	}
+
+	prepared := ShortenHost(generated, maxLabelLength)
+	if prepared != generated && mapping != nil {
+		mapping.Shortened[generated] = prepared
+	}
+
+	if err := ValidateHost(prepared); err != nil && mapping != nil {
+		mapping.Invalid = append(mapping.Invalid, err)
+	} else if err != nil {
+		log.WithField("kind", r.Kind).WithField("source", MustGetKey(source)).WithError(err).
+			Warnf("prepared host %s is not valid", prepared)
+	}
+
+	return prepared
+}
+
+// generateHost executes the host template of the source, falling back to replacing the first label of the host
+func (r *GenericReplicator) generateHost(namespace string, source metav1.Object, host string) string {
+	if tmpl := r.hostTemplate(source); tmpl != nil {
+		generated, err := ExecuteHostTemplate(tmpl, r.hostTemplateData(namespace, host))
+		if err == nil {
+			return generated
+		}
+
+		log.WithField("kind", r.Kind).WithField("source", MustGetKey(source)).WithError(err).
+			Warnf("host %s is prepared by replacing its first label with the namespace", host)
+	}
+
+	return PrepareTLD(namespace, host)
+}
+
+// hostTemplate returns the template of the HostTemplate annotation of the source or the configured HostTemplate,
+// which is nil if neither is set. The annotation is only parsed again when its text changes, so that an invalid
+// template is logged once instead of for every host, after which its hosts are prepared without a template.
+func (r *GenericReplicator) hostTemplate(source metav1.Object) *template.Template {
+	key := MustGetKey(source)
+	text, ok := source.GetAnnotations()[HostTemplate]
+	if !ok {
+		r.hostTemplates.Delete(key)
+		return r.HostTemplate
+	}
+
+	if cached, ok := r.hostTemplates.Load(key); ok && cached.(parsedHostTemplate).text == text {
+		return cached.(parsedHostTemplate).tmpl
+	}
+
+	tmpl, err := ParseHostTemplate(text)
+	if err != nil {
+		log.WithField("kind", r.Kind).WithField("source", key).WithError(err).
+			Warn("hosts are prepared by replacing their first label with the namespace")
+	}
+	r.hostTemplates.Store(key, parsedHostTemplate{text: text, tmpl: tmpl})
+
+	return tmpl
+}
+
+// parsedHostTemplate is the template parsed from the text of a HostTemplate annotation, which is nil if it is invalid
+type parsedHostTemplate struct {
+	text string
+	tmpl *template.Template
+}
+
+// hostTemplateData looks up the labels and annotations of the namespace in the shared cache
+func (r *GenericReplicator) hostTemplateData(namespace, host string) HostTemplateData {
+	data := HostTemplateData{Namespace: namespace, Host: NewHost(host)}
+
+	if r.NamespaceWatcher != nil {
+		if ns, err := r.NamespaceWatcher.Lister.Get(namespace); err == nil {
+			data.NamespaceLabels = ns.Labels
+			data.NamespaceAnnotations = ns.Annotations
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newHostTemplateReplicator(t *testing.T, defaultTemplate string) *GenericReplicator {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	assert.NoError(t, indexer.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "feature-a",
+		Labels:      map[string]string{"feature-branch": "coolnewthing"},
+		Annotations: map[string]string{"owner": "team-a"},
+	}}))
+
+	repl := &GenericReplicator{ReplicatorConfig: ReplicatorConfig{
+		Kind:             "Ingress",
+		NamespaceWatcher: &NamespaceWatcher{Lister: corelisters.NewNamespaceLister(indexer)},
+	}}
+
+	if len(defaultTemplate) > 0 {
+		tmpl, err := ParseHostTemplate(defaultTemplate)
+		assert.NoError(t, err)
+		repl.HostTemplate = tmpl
+	}
+
+	return repl
+}
+
+func hostTemplateSource(text string) *v1.Service {
+	source := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}}
+	if len(text) > 0 {
+		source.Annotations = map[string]string{HostTemplate: text}
+	}
+
+	return source
+}
+
+func Test_Host(t *testing.T) {
+	host := NewHost("api.example.com")
+
+	assert.Equal(t, "api.example.com", host.String())
+	assert.Equal(t, "api", host.FirstLabel())
+	assert.Equal(t, "example.com", host.Domain())
+	assert.Equal(t, []string{"api", "example", "com"}, host.Labels)
+}
+
+func Test_GenericReplicator_PrepareTLD(t *testing.T) {
+	cases := map[string]string{
+		"": "feature-a.example.com",
+		"{{ .Namespace }}--{{ .Host.FirstLabel }}.preview.example.com":  "feature-a--api.preview.example.com",
+		`api.{{ index .NamespaceLabels "feature-branch" }}.example.com`: "api.coolnewthing.example.com",
+		"{{ .NamespaceAnnotations.owner }}.{{ .Host.Domain }}":          "team-a.example.com",
+		" {{ .Host }} ": "api.example.com",
+	}
+
+	repl := newHostTemplateReplicator(t, "")
+	for text, expected := range cases {
+		assert.Equal(t, expected, repl.PrepareTLD("feature-a", hostTemplateSource(text), "api.example.com"), text)
+	}
+}
+
+func Test_GenericReplicator_PrepareTLD_Default(t *testing.T) {
+	repl := newHostTemplateReplicator(t, "{{ .Namespace }}--{{ .Host.FirstLabel }}.preview.example.com")
+
+	assert.Equal(t, "feature-a--api.preview.example.com", repl.PrepareTLD("feature-a", hostTemplateSource(""), "api.example.com"))
+
+	// the annotation takes precedence over the default template
+	source := hostTemplateSource("{{ .Host.FirstLabel }}.{{ .Namespace }}.example.com")
+	assert.Equal(t, "api.feature-a.example.com", repl.PrepareTLD("feature-a", source, "api.example.com"))
+}
+
+func Test_GenericReplicator_PrepareTLD_Fallback(t *testing.T) {
+	cases := []string{
+		"{{ .Namespace",
+		"{{ .NamespaceLabels.team }}.example.com",
+		"{{ .Unknown }}.example.com",
+		`{{ if false }}{{ .Namespace }}{{ end }}`,
+	}
+
+	repl := newHostTemplateReplicator(t, "")
+	for _, text := range cases {
+		assert.Equal(t, "feature-a.example.com", repl.PrepareTLD("feature-a", hostTemplateSource(text), "api.example.com"), text)
+	}
+}
+
+func Test_ParseHostTemplate_Error(t *testing.T) {
+	_, err := ParseHostTemplate("{{ .Namespace")
+	assert.ErrorContains(t, err, "invalid host template")
+}
+
+func Test_GenericReplicator_hostTemplate_Cached(t *testing.T) {
+	repl := newHostTemplateReplicator(t, "")
+
+	tmpl := repl.hostTemplate(hostTemplateSource("{{ .Namespace }}.example.com"))
+	assert.NotNil(t, tmpl)
+	assert.Same(t, tmpl, repl.hostTemplate(hostTemplateSource("{{ .Namespace }}.example.com")))
+
+	// editing the annotation replaces the cached template of the source, invalid templates are only parsed once
+	assert.Nil(t, repl.hostTemplate(hostTemplateSource("{{ .Namespace")))
+	cached, ok := repl.hostTemplates.Load("default/nginx")
+	assert.True(t, ok)
+	assert.Equal(t, parsedHostTemplate{text: "{{ .Namespace"}, cached)
+
+	// removing the annotation evicts the cached template
+	assert.Nil(t, repl.hostTemplate(hostTemplateSource("")))
+	_, ok = repl.hostTemplates.Load("default/nginx")
+	assert.False(t, ok)
+}
@@ -0,0 +1,201 @@
+package common
+
+import (
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ReplicationRule describes which namespaces a source resource is replicated to.
+type ReplicationRule struct {
+	// Namespace of the source resource, which is never a replication target.
+	Namespace string
+
+	// Patterns is the raw value of the "replicate-to" annotation, empty if not present.
+	Patterns string
+	patterns []*regexp.Regexp
+
+	// Selector is the parsed "replicate-to-matching" annotation, nil if not present.
+	Selector labels.Selector
+}
+
+// HasReplicationAnnotations checks whether the resource has either of the "replicate-to" or "replicate-to-matching" annotations.
+func HasReplicationAnnotations(source metav1.Object) bool {
+	annotations := source.GetAnnotations()
+	_, replicateTo := annotations[ReplicateTo]
+	_, replicateToMatching := annotations[ReplicateToMatching]
+
+	return replicateTo || replicateToMatching
+}
+
+// NewReplicationRule builds the ReplicationRule of the provided source resource from its annotations.
+// An error is returned alongside a usable rule if the "replicate-to-matching" selector could not be parsed.
+func NewReplicationRule(source metav1.Object) (rule ReplicationRule, err error) {
+	rule.Namespace = source.GetNamespace()
+	annotations := source.GetAnnotations()
+
+	if patterns, ok := annotations[ReplicateTo]; ok {
+		rule.Patterns = patterns
+		rule.patterns = StringToPatternList(patterns)
+	}
+
+	if selector, ok := annotations[ReplicateToMatching]; ok {
+		if rule.Selector, err = labels.Parse(selector); err != nil {
+			rule.Selector = nil
+			err = errors.Wrapf(err, "failed to parse label selector %q", selector)
+		}
+	}
+
+	return
+}
+
+// Matches checks whether the provided namespace is a replication target of this rule.
+func (rule ReplicationRule) Matches(namespace *v1.Namespace) bool {
+	if namespace.Name == rule.Namespace {
+		return false
+	}
+
+	for _, pattern := range rule.patterns {
+		if pattern.MatchString(namespace.Name) {
+			return true
+		}
+	}
+
+	return rule.Selector != nil && rule.Selector.Matches(labels.Set(namespace.Labels))
+}
+
+type indexEntry struct {
+	rule    ReplicationRule
+	targets map[string]struct{}
+}
+
+// ReplicationIndex is a thread-safe index of source resource keys to the rule selecting their target namespaces
+// and the set of namespaces they have been replicated to.
+type ReplicationIndex struct {
+	mu      sync.RWMutex
+	entries map[string]*indexEntry
+}
+
+// NewReplicationIndex creates an empty ReplicationIndex
+func NewReplicationIndex() *ReplicationIndex {
+	return &ReplicationIndex{
+		entries: make(map[string]*indexEntry),
+	}
+}
+
+// entry returns the entry for the provided key, creating it if needed. The write lock must be held.
+func (idx *ReplicationIndex) entry(key string) *indexEntry {
+	e, ok := idx.entries[key]
+	if !ok {
+		e = &indexEntry{targets: make(map[string]struct{})}
+		idx.entries[key] = e
+	}
+	return e
+}
+
+// Has checks whether the provided source key is indexed
+func (idx *ReplicationIndex) Has(key string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	_, ok := idx.entries[key]
+	return ok
+}
+
+// SetRule records the replication rule of a source, keeping the targets it was already replicated to.
+func (idx *ReplicationIndex) SetRule(key string, rule ReplicationRule) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.entry(key).rule = rule
+}
+
+// Rule returns the replication rule recorded for a source
+func (idx *ReplicationIndex) Rule(key string) (ReplicationRule, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	e, ok := idx.entries[key]
+	if !ok {
+		return ReplicationRule{}, false
+	}
+	return e.rule, true
+}
+
+// Delete removes a source from the index
+func (idx *ReplicationIndex) Delete(key string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	delete(idx.entries, key)
+}
+
+// AddTarget records that a source has been replicated to the provided namespace
+func (idx *ReplicationIndex) AddTarget(key, namespace string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.entry(key).targets[namespace] = struct{}{}
+}
+
+// RemoveTarget records that a source is no longer replicated to the provided namespace
+func (idx *ReplicationIndex) RemoveTarget(key, namespace string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if e, ok := idx.entries[key]; ok {
+		delete(e.targets, namespace)
+	}
+}
+
+// Targets returns the sorted namespaces a source has been replicated to
+func (idx *ReplicationIndex) Targets(key string) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	e, ok := idx.entries[key]
+	if !ok {
+		return nil
+	}
+
+	targets := make([]string, 0, len(e.targets))
+	for namespace := range e.targets {
+		targets = append(targets, namespace)
+	}
+	sort.Strings(targets)
+
+	return targets
+}
+
+// SelectingKeys returns the keys of all sources whose rule selects the provided namespace
+func (idx *ReplicationIndex) SelectingKeys(namespace *v1.Namespace) (keys []string) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for key, e := range idx.entries {
+		if e.rule.Matches(namespace) {
+			keys = append(keys, key)
+		}
+	}
+
+	return
+}
+
+// TargetingKeys returns the keys of all sources that have been replicated to the provided namespace
+func (idx *ReplicationIndex) TargetingKeys(namespace string) (keys []string) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	for key, e := range idx.entries {
+		if _, ok := e.targets[namespace]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,121 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testNamespace(name string, labels map[string]string) *v1.Namespace {
+	return &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func Test_NewReplicationRule(t *testing.T) {
+	rule, err := NewReplicationRule(&metav1.ObjectMeta{
+		Namespace: "default",
+		Annotations: map[string]string{
+			ReplicateTo:         "feature-.*, other",
+			ReplicateToMatching: "feature-branch",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "feature-.*, other", rule.Patterns)
+	assert.Equal(t, "feature-branch", rule.Selector.String())
+
+	rule, err = NewReplicationRule(&metav1.ObjectMeta{
+		Namespace: "default",
+		Annotations: map[string]string{
+			ReplicateTo:         "feature-.*",
+			ReplicateToMatching: "!!invalid",
+		},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, rule.Selector)
+	assert.True(t, rule.Matches(testNamespace("feature-one", nil)))
+}
+
+func Test_ReplicationRule_Matches(t *testing.T) {
+	rule, err := NewReplicationRule(&metav1.ObjectMeta{
+		Namespace: "default",
+		Annotations: map[string]string{
+			ReplicateTo:         "feature-.*, other",
+			ReplicateToMatching: "feature-branch",
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, rule.Matches(testNamespace("feature-one", nil)))
+	assert.True(t, rule.Matches(testNamespace("other", nil)))
+	assert.True(t, rule.Matches(testNamespace("labeled", map[string]string{"feature-branch": "labeled"})))
+	assert.False(t, rule.Matches(testNamespace("another", nil)))
+	assert.False(t, rule.Matches(testNamespace("default", map[string]string{"feature-branch": "default"})))
+}
+
+func Test_ReplicationIndex(t *testing.T) {
+	idx := NewReplicationIndex()
+	rule, _ := NewReplicationRule(&metav1.ObjectMeta{
+		Namespace:   "default",
+		Annotations: map[string]string{ReplicateTo: "feature-.*"},
+	})
+
+	idx.SetRule("default/nginx", rule)
+	idx.AddTarget("default/nginx", "feature-b")
+	idx.AddTarget("default/nginx", "feature-a")
+	idx.AddTarget("default/nginx", "other")
+
+	assert.True(t, idx.Has("default/nginx"))
+	assert.Equal(t, []string{"feature-a", "feature-b", "other"}, idx.Targets("default/nginx"))
+	assert.Equal(t, []string{"default/nginx"}, idx.SelectingKeys(testNamespace("feature-c", nil)))
+	assert.Empty(t, idx.SelectingKeys(testNamespace("other", nil)))
+	assert.Equal(t, []string{"default/nginx"}, idx.TargetingKeys("other"))
+
+	idx.RemoveTarget("default/nginx", "other")
+	assert.Empty(t, idx.TargetingKeys("other"))
+
+	// updating the rule keeps existing targets
+	idx.SetRule("default/nginx", ReplicationRule{Namespace: "default"})
+	assert.Equal(t, []string{"feature-a", "feature-b"}, idx.Targets("default/nginx"))
+
+	idx.Delete("default/nginx")
+	assert.False(t, idx.Has("default/nginx"))
+	assert.Empty(t, idx.Targets("default/nginx"))
+}
+
+func Test_ReplicationIndex_Concurrent(t *testing.T) {
+	idx := NewReplicationIndex()
+	rule, _ := NewReplicationRule(&metav1.ObjectMeta{
+		Namespace:   "default",
+		Annotations: map[string]string{ReplicateTo: "feature-.*"},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("default/resource-%d", i)
+			for j := 0; j < 100; j++ {
+				idx.SetRule(key, rule)
+				idx.AddTarget(key, fmt.Sprintf("feature-%d", j))
+				idx.RemoveTarget(key, fmt.Sprintf("feature-%d", j-1))
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				idx.SelectingKeys(testNamespace(fmt.Sprintf("feature-%d", j), nil))
+				idx.TargetingKeys(fmt.Sprintf("feature-%d", j))
+				idx.Targets(fmt.Sprintf("default/resource-%d", i))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 8; i++ {
+		assert.Equal(t, []string{"feature-99"}, idx.Targets(fmt.Sprintf("default/resource-%d", i)))
+	}
+}
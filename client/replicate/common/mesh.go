@@ -0,0 +1,21 @@
+package common
+
+import "fmt"
+
+// MeshExternalNameSuffix returns the ExternalName suffix of Services replicated within the provided service mesh.
+// Services outside of a mesh resolve to "svc.<cluster domain>", ServiceMeshIstio is an alias of ServiceMeshNone.
+// The second return value is false for unknown meshes.
+func MeshExternalNameSuffix(mesh, clusterDomain string) (string, bool) {
+	if len(clusterDomain) == 0 {
+		clusterDomain = DefaultClusterDomain
+	}
+
+	switch mesh {
+	case "", ServiceMeshNone, ServiceMeshIstio:
+		return fmt.Sprintf("svc.%s", clusterDomain), true
+	case ServiceMeshTraefik:
+		return TraefikMeshExternalNameSuffix, true
+	}
+
+	return DefaultExternalNameSuffix, false
+}
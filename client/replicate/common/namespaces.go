@@ -1,84 +1,51 @@
 package common
 
 import (
-	"context"
-	"sync"
-	"time"
-
-	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/kubernetes"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
-var namespaceWatcher NamespaceWatcher
-
 type AddFunc func(obj *v1.Namespace)
 
 type UpdateFunc func(old *v1.Namespace, new *v1.Namespace)
 
+// NamespaceWatcher shares a single Namespace informer between all replicators
 type NamespaceWatcher struct {
-	doOnce sync.Once
-
-	NamespaceStore      cache.Store
-	NamespaceController cache.Controller
+	Informer cache.SharedIndexInformer
 
-	AddFuncs    []AddFunc
-	UpdateFuncs []UpdateFunc
+	// Lister serves namespace lookups from the shared informer cache
+	Lister corelisters.NamespaceLister
 }
 
-// OnNamespaceAdded will add another method to a list of functions to be called when a new namespace is created
-func (nw *NamespaceWatcher) OnNamespaceAdded(ctx context.Context, client kubernetes.Interface, resyncPeriod time.Duration, addFunc AddFunc) {
-	nw.create(ctx, client, resyncPeriod)
-	nw.AddFuncs = append(nw.AddFuncs, addFunc)
+// NewNamespaceWatcher creates a new NamespaceWatcher backed by the provided shared informer
+func NewNamespaceWatcher(informer coreinformers.NamespaceInformer) *NamespaceWatcher {
+	return &NamespaceWatcher{
+		Informer: informer.Informer(),
+		Lister:   informer.Lister(),
+	}
 }
 
-// OnNamespaceUpdated will add another method to a list of functions to be called when a namespace is updated
-func (nw *NamespaceWatcher) OnNamespaceUpdated(ctx context.Context, client kubernetes.Interface, resyncPeriod time.Duration, updateFunc UpdateFunc) {
-	nw.create(ctx, client, resyncPeriod)
-	nw.UpdateFuncs = append(nw.UpdateFuncs, updateFunc)
+// HasSynced reports whether or not the namespace cache has been synced
+func (nw *NamespaceWatcher) HasSynced() bool {
+	return nw.Informer.HasSynced()
 }
 
-// create will create a new namespace if one does not already exist. If it does, it will do nothing.
-func (nw *NamespaceWatcher) create(ctx context.Context, client kubernetes.Interface, resyncPeriod time.Duration) {
-	nw.doOnce.Do(func() {
-		namespaceAdded := func(obj interface{}) {
-			namespace := obj.(*v1.Namespace)
-			for _, addFunc := range nw.AddFuncs {
-				go addFunc(namespace)
-			}
-		}
-
-		namespaceUpdated := func(old interface{}, new interface{}) {
-			nsOld := old.(*v1.Namespace)
-			nsNew := new.(*v1.Namespace)
-			for _, updateFunc := range nw.UpdateFuncs {
-				go updateFunc(nsOld, nsNew)
-			}
-		}
-
-		nw.NamespaceStore, nw.NamespaceController = cache.NewInformer(
-			&cache.ListWatch{
-				ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
-					return client.CoreV1().Namespaces().List(ctx, lo)
-				},
-				WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-					return client.CoreV1().Namespaces().Watch(ctx, lo)
-				},
-			},
-			&v1.Namespace{},
-			resyncPeriod,
-			cache.ResourceEventHandlerFuncs{
-				AddFunc:    namespaceAdded,
-				UpdateFunc: namespaceUpdated,
-			},
-		)
+// OnNamespaceAdded will add another method to a list of functions to be called when a new namespace is created
+func (nw *NamespaceWatcher) OnNamespaceAdded(addFunc AddFunc) {
+	_, _ = nw.Informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			addFunc(obj.(*v1.Namespace))
+		},
+	})
+}
 
-		log.WithField("kind", "Namespace").Infof("running Namespace controller")
-		go nw.NamespaceController.Run(wait.NeverStop)
+// OnNamespaceUpdated will add another method to a list of functions to be called when a namespace is updated
+func (nw *NamespaceWatcher) OnNamespaceUpdated(updateFunc UpdateFunc) {
+	_, _ = nw.Informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old interface{}, new interface{}) {
+			updateFunc(old.(*v1.Namespace), new.(*v1.Namespace))
+		},
 	})
 }
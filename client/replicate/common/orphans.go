@@ -0,0 +1,120 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OrphanPolicy determines what happens to replicated resources whose source no longer selects them
+type OrphanPolicy string
+
+// Orphan policy options
+const (
+	OrphanPolicyDelete OrphanPolicy = "delete"
+	OrphanPolicyReport OrphanPolicy = "report"
+)
+
+// collectOrphans is a wrapper of CollectOrphans to be run periodically
+func (r *GenericReplicator) collectOrphans(ctx context.Context) {
+	if err := r.CollectOrphans(); err != nil {
+		log.WithField("kind", r.Kind).WithError(err).Error("failed to collect orphaned resources")
+	}
+}
+
+// CollectOrphans sweeps all managed resources and deletes, or reports, those whose source resource
+// no longer exists or no longer selects their namespace. This cleans up replicas that were orphaned
+// while the controller was not running.
+func (r *GenericReplicator) CollectOrphans() (err error) {
+	logger := log.WithField("kind", r.Kind)
+	logger.Debugf("collecting orphaned %ss", r.Kind)
+
+	for _, obj := range r.Store.List() {
+		target := MustGetObject(obj)
+		if !IsManagedBy(target) {
+			continue
+		}
+
+		sourceKey, ok := target.GetAnnotations()[ReplicatedFromAnnotation]
+		if !ok {
+			continue
+		}
+
+		reason, orphaned := r.isOrphan(sourceKey, target)
+		if !orphaned {
+			continue
+		}
+
+		targetKey := MustGetKey(target)
+		logger := logger.WithField("source", sourceKey).WithField("target", targetKey)
+
+		if r.OrphanPolicy == OrphanPolicyReport {
+			logger.Warnf("%s %s is orphaned: %s", r.Kind, targetKey, reason)
+			continue
+		}
+
+		logger.Infof("Deleting orphaned %s %s: %s", r.Kind, targetKey, reason)
+		if innerErr := r.UpdateFuncs.DeleteReplicatedResource(obj); innerErr != nil && !apierrors.IsNotFound(innerErr) {
+			err = multierror.Append(err, errors.Wrapf(innerErr, "Could not delete orphaned resource %s", targetKey))
+			continue
+		}
+
+		r.Index.RemoveTarget(sourceKey, target.GetNamespace())
+	}
+
+	return
+}
+
+// isOrphan checks whether the target is still selected by, or referenced alongside, the source it was replicated from
+func (r *GenericReplicator) isOrphan(sourceKey string, target metav1.Object) (reason string, orphaned bool) {
+	obj, exists, err := r.Store.GetByKey(sourceKey)
+	if err != nil {
+		return "", false
+	}
+
+	if !exists {
+		return fmt.Sprintf("source %s no longer exists", sourceKey), true
+	}
+
+	source := MustGetObject(obj)
+	if IsManagedBy(source) || source.GetName() != target.GetName() {
+		return "", false
+	}
+
+	namespace, err := r.NamespaceWatcher.Lister.Get(target.GetNamespace())
+	if err != nil {
+		return "", false
+	}
+
+	referencing, err := r.ListReferencingNamespaces(source)
+	if err != nil {
+		return "", false
+	}
+
+	for _, n := range referencing {
+		if n.Name == namespace.Name {
+			return "", false
+		}
+	}
+
+	if !HasReplicationAnnotations(source) {
+		return fmt.Sprintf("source %s is no longer replicated", sourceKey), true
+	}
+
+	rule, err := NewReplicationRule(source)
+	if err != nil {
+		// an invalid selector is reported during reconciliation and should not remove existing replicas
+		return "", false
+	}
+
+	if !rule.Matches(namespace) {
+		return fmt.Sprintf("source %s no longer selects namespace %s", sourceKey, namespace.Name), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testReplica(namespace string, name string, source string) *v1.Service {
+	return &v1.Service{ObjectMeta: metav1.ObjectMeta{
+		Name:        name,
+		Namespace:   namespace,
+		Labels:      map[string]string{ManagedByLabelKey: ManagedByLabelValue},
+		Annotations: map[string]string{ReplicatedFromAnnotation: source},
+	}}
+}
+
+func orphanTestObjects() []runtime.Object {
+	return []runtime.Object{
+		testNamespace("feature-0", nil),
+		testNamespace("branch-0", nil),
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        "service-0",
+			Namespace:   "default",
+			Annotations: map[string]string{ReplicateTo: "feature-.*"},
+		}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:      "service-1",
+			Namespace: "default",
+		}},
+		// still selected
+		testReplica("feature-0", "service-0", "default/service-0"),
+		// namespace no longer selected
+		testReplica("branch-0", "service-0", "default/service-0"),
+		// source no longer replicated
+		testReplica("feature-0", "service-1", "default/service-1"),
+		// source deleted
+		testReplica("feature-0", "service-2", "default/service-2"),
+	}
+}
+
+func Test_CollectOrphans(t *testing.T) {
+	tests := []struct {
+		policy   OrphanPolicy
+		expected []string
+	}{
+		{
+			policy:   OrphanPolicyDelete,
+			expected: []string{"feature-0/service-0"},
+		},
+		{
+			policy:   OrphanPolicyReport,
+			expected: []string{"branch-0/service-0", "feature-0/service-0", "feature-0/service-1", "feature-0/service-2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			ctx := context.Background()
+
+			client := fake.NewSimpleClientset(orphanTestObjects()...)
+			repl, factory := newTestReplicator(ctx, client)
+			repl.OrphanPolicy = test.policy
+			defer repl.Queue.ShutDown()
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			factory.Start(stopCh)
+			factory.WaitForCacheSync(stopCh)
+
+			assert.NoError(t, repl.CollectOrphans())
+
+			services, err := client.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+			assert.NoError(t, err)
+
+			var replicas []string
+			for i := range services.Items {
+				if IsManagedBy(&services.Items[i]) {
+					replicas = append(replicas, MustGetKey(&services.Items[i]))
+				}
+			}
+			assert.ElementsMatch(t, test.expected, replicas)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ReferenceSource is implemented by replicators whose replicated resources reference resources of other kinds
+// from their source namespace, such as an Ingress referencing its TLS Secret.
+type ReferenceSource interface {
+	Replicator
+
+	// GetStore returns the cache of the replicated kind
+	GetStore() cache.Store
+
+	// OnReconciled registers a function that is called with the key of every resource after it has been reconciled
+	OnReconciled(func(key string))
+
+	// References lists the names of the resources of the provided kind that the replicas of the source reference
+	References(kind string, source interface{}) []string
+
+	// replicatedNamespaces lists the namespaces the source is replicated to
+	replicatedNamespaces(source metav1.Object, visited map[visitedSource]struct{}) ([]*v1.Namespace, error)
+}
+
+// visitedSource is a source of a replicator that has been visited while following references
+type visitedSource struct {
+	replicator *GenericReplicator
+	key        string
+}
+
+// referenceSource tracks the resources referenced by the sources of a ReferenceSource
+type referenceSource struct {
+	ReferenceSource
+
+	mu sync.RWMutex
+	// references maps the key of each source to the resources it references
+	references map[string]references
+}
+
+// references are the keys of the resources referenced by a source and the namespaces the source is replicated to
+type references struct {
+	keys       []string
+	namespaces []string
+}
+
+// set records the references of a source and returns the previous references
+func (rs *referenceSource) set(sourceKey string, refs references) references {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	previous := rs.references[sourceKey]
+	if len(refs.keys) == 0 {
+		delete(rs.references, sourceKey)
+	} else {
+		rs.references[sourceKey] = refs
+	}
+
+	return previous
+}
+
+// referenced checks whether any source references the provided key
+func (rs *referenceSource) referenced(key string) bool {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	for _, refs := range rs.references {
+		for _, referenced := range refs.keys {
+			if referenced == key {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GetStore returns the cache of the replicated kind
+func (r *GenericReplicator) GetStore() cache.Store {
+	return r.Store
+}
+
+// OnReconciled registers a function that is called with the key of every resource after it has been reconciled
+func (r *GenericReplicator) OnReconciled(reconciled func(key string)) {
+	r.reconciled = append(r.reconciled, reconciled)
+}
+
+// ReplicateReferencedBy replicates resources that are referenced by the replicas of the provided replicators into the
+// same namespaces as the replicas. Replicas of referenced resources are deleted once they are no longer referenced.
+// Replicators that do not implement ReferenceSource are ignored.
+func (r *GenericReplicator) ReplicateReferencedBy(replicators ...Replicator) {
+	for _, replicator := range replicators {
+		source, ok := replicator.(ReferenceSource)
+		if !ok {
+			continue
+		}
+
+		rs := &referenceSource{ReferenceSource: source, references: make(map[string]references)}
+		r.referenceSources = append(r.referenceSources, rs)
+
+		source.OnReconciled(func(sourceKey string) {
+			r.referencesChanged(rs, sourceKey)
+		})
+	}
+}
+
+// referencesChanged queues the resources that are, or were previously, referenced by a reconciled source if either
+// the references or the namespaces of the source changed. Reconciling unchanged sources does not queue anything,
+// so that resources referencing each other are not reconciled indefinitely.
+func (r *GenericReplicator) referencesChanged(rs *referenceSource, sourceKey string) {
+	var refs references
+	if obj, exists, err := rs.GetStore().GetByKey(sourceKey); err == nil && exists {
+		namespaces, err := rs.replicatedNamespaces(MustGetObject(obj), make(map[visitedSource]struct{}))
+		if err != nil {
+			log.WithField("kind", r.Kind).WithField("source", sourceKey).WithError(err).Error("failed to list namespaces")
+		}
+
+		if len(namespaces) > 0 {
+			refs.keys = r.referencedKeys(rs, obj)
+			for _, namespace := range namespaces {
+				refs.namespaces = append(refs.namespaces, namespace.Name)
+			}
+			sort.Strings(refs.namespaces)
+		}
+	}
+
+	previous := rs.set(sourceKey, refs)
+	if reflect.DeepEqual(previous, refs) {
+		return
+	}
+
+	for _, key := range append(previous.keys, refs.keys...) {
+		r.Queue.Add(key)
+	}
+}
+
+// referencedKeys lists the keys of the resources of this kind that the replicas of the source reference
+func (r *GenericReplicator) referencedKeys(rs *referenceSource, obj interface{}) (keys []string) {
+	source := MustGetObject(obj)
+	if IsManagedBy(source) {
+		return nil
+	}
+
+	for _, name := range rs.References(r.Kind, obj) {
+		keys = append(keys, fmt.Sprintf("%s/%s", source.GetNamespace(), name))
+	}
+
+	return keys
+}
+
+// isReferenced checks whether the resource is referenced by any replicated resource
+func (r *GenericReplicator) isReferenced(key string) bool {
+	for _, rs := range r.referenceSources {
+		if rs.referenced(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListReferencingNamespaces lists the namespaces that resources referencing the provided resource are replicated to.
+// Referencing resources are replicated by their own annotations or, in turn, by the resources referencing them.
+func (r *GenericReplicator) ListReferencingNamespaces(referenced metav1.Object) ([]*v1.Namespace, error) {
+	return r.referencingNamespaces(referenced, make(map[visitedSource]struct{}))
+}
+
+// referencingNamespaces lists the namespaces that resources referencing the provided resource are replicated to,
+// skipping the resources that have already been visited
+func (r *GenericReplicator) referencingNamespaces(referenced metav1.Object, visited map[visitedSource]struct{}) (selected []*v1.Namespace, err error) {
+	key := MustGetKey(referenced)
+
+	for _, rs := range r.referenceSources {
+		for _, obj := range rs.GetStore().List() {
+			if MustGetObject(obj).GetNamespace() != referenced.GetNamespace() || !containsString(r.referencedKeys(rs, obj), key) {
+				continue
+			}
+
+			namespaces, err := rs.replicatedNamespaces(MustGetObject(obj), visited)
+			if err != nil {
+				return nil, err
+			}
+
+			selected = mergeNamespaces(selected, namespaces)
+		}
+	}
+
+	return selected, nil
+}
+
+// replicatedNamespaces lists the namespaces the source is replicated to, which are selected by its replication rule
+// or by the resources referencing it. Every resource is only visited once, so that cyclic references terminate.
+func (r *GenericReplicator) replicatedNamespaces(source metav1.Object, visited map[visitedSource]struct{}) ([]*v1.Namespace, error) {
+	visit := visitedSource{replicator: r, key: MustGetKey(source)}
+	if _, ok := visited[visit]; ok || IsManagedBy(source) {
+		return nil, nil
+	}
+	visited[visit] = struct{}{}
+
+	referencing, err := r.referencingNamespaces(source, visited)
+	if err != nil || !HasReplicationAnnotations(source) {
+		return referencing, err
+	}
+
+	// invalid rules are reported by the replicator of the referencing resource
+	rule, _ := NewReplicationRule(source)
+	namespaces, err := r.ListRuleNamespaces(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeNamespaces(namespaces, referencing), nil
+}
+
+// mergeNamespaces appends the namespaces that are not yet part of the selected namespaces
+func mergeNamespaces(selected []*v1.Namespace, namespaces []*v1.Namespace) []*v1.Namespace {
+	for _, namespace := range namespaces {
+		duplicate := false
+		for _, s := range selected {
+			if s.Name == namespace.Name {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			selected = append(selected, namespace)
+		}
+	}
+
+	return selected
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
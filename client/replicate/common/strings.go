@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -74,3 +75,91 @@ func PrepareTLD(namespace, tld string) string {
 
 	return strings.Join(subdomains, ".")
 }
+
+// StringToList splits a CSV list into its trimmed, non-empty values
+func StringToList(list string) (result []string) {
+	for _, s := range strings.Split(list, ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			result = append(result, s)
+		}
+	}
+
+	return
+}
+
+// ReplaceHostsFunc replaces every occurrence of the provided hosts in the value with the host prepared by prepareTLD.
+// Hosts are only replaced if they are not part of a longer hostname, so that "example.com" does not match "api.example.com".
+func ReplaceHostsFunc(value string, hosts []string, prepareTLD func(host string) string) string {
+	// longer hosts are replaced first, so that they are not partially rewritten by a shorter host
+	sorted := make([]string, len(hosts))
+	copy(sorted, hosts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+
+	replaced := make([]bool, len(value))
+	var replacements []hostReplacement
+	for _, host := range sorted {
+		prepared := prepareTLD(host)
+
+		for offset := 0; offset < len(value); {
+			index := strings.Index(value[offset:], host)
+			if index < 0 {
+				break
+			}
+
+			start, end := offset+index, offset+index+len(host)
+			offset = start + 1
+
+			if replaced[start] || replaced[end-1] || isHostByte(value, start-1) || continuesHost(value, end) {
+				continue
+			}
+
+			for i := start; i < end; i++ {
+				replaced[i] = true
+			}
+			replacements = append(replacements, hostReplacement{start: start, end: end, host: prepared})
+			offset = end
+		}
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var builder strings.Builder
+	last := 0
+	for _, replacement := range replacements {
+		builder.WriteString(value[last:replacement.start])
+		builder.WriteString(replacement.host)
+		last = replacement.end
+	}
+	builder.WriteString(value[last:])
+
+	return builder.String()
+}
+
+type hostReplacement struct {
+	start, end int
+	host       string
+}
+
+// continuesHost checks whether a hostname continues at the index of the value, a trailing dot ends a hostname
+func continuesHost(value string, index int) bool {
+	if index < len(value) && value[index] == '.' {
+		return isHostByte(value, index+1) && value[index+1] != '.'
+	}
+
+	return isHostByte(value, index)
+}
+
+// isHostByte checks whether the byte at the index of the value can be part of a hostname
+func isHostByte(value string, index int) bool {
+	if index < 0 || index >= len(value) {
+		return false
+	}
+
+	c := value[index]
+	return c == '-' || c == '.' || c == '_' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ReplaceHostsFunc(t *testing.T) {
+	hosts := []string{"example.com", "api.example.com", "app.example.com"}
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{
+			value:    "https://api.example.com/v1",
+			expected: "https://feature.example.com/v1",
+		},
+		{
+			value:    `{"api": "api.example.com", "app": "app.example.com:8080"}`,
+			expected: `{"api": "feature.example.com", "app": "feature.example.com:8080"}`,
+		},
+		{
+			value:    "Visit app.example.com.",
+			expected: "Visit feature.example.com.",
+		},
+		{
+			value:    "cdn.example.com,myapi.example.com",
+			expected: "cdn.example.com,myapi.example.com",
+		},
+		{
+			value:    "example.com",
+			expected: "feature.com",
+		},
+		{
+			value:    "no hosts",
+			expected: "no hosts",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			assert.Equal(t, test.expected, ReplaceHostsFunc(test.value, hosts, func(host string) string {
+				return PrepareTLD("feature", host)
+			}))
+		})
+	}
+}
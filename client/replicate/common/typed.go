@@ -0,0 +1,167 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Object is a Kubernetes resource that can be replicated by a TypedReplicator
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Client is the subset of a generated typed client of a kind, scoped to a single namespace, used by a TypedReplicator
+type Client[T Object] interface {
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (T, error)
+	Create(ctx context.Context, obj T, opts metav1.CreateOptions) (T, error)
+	Update(ctx context.Context, obj T, opts metav1.UpdateOptions) (T, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+}
+
+// Adapter provides the kind-specific parts of a TypedReplicator
+type Adapter[T Object] interface {
+	// ResourceClient returns the typed client of the kind in the provided namespace
+	ResourceClient(namespace string) Client[T]
+
+	// GroupVersionKind is the kind that is replicated, used for server-side apply
+	GroupVersionKind() schema.GroupVersionKind
+
+	// Prepare builds the replica of the source in the provided namespace, including the ReplicatedHashAnnotation
+	Prepare(namespace string, source T) T
+
+	// IsDrifted compares the fields of the target that are set by Prepare
+	IsDrifted(prepared T, target T) bool
+}
+
+// TypedReplicator implements the UpdateFuncs of a GenericReplicator for a typed kind, so that a new kind only has to
+// provide an Adapter
+type TypedReplicator[T Object] struct {
+	*GenericReplicator
+	Adapter Adapter[T]
+}
+
+// NewTypedReplicator creates a new GenericReplicator whose UpdateFuncs replicate the kind with the provided adapter
+func NewTypedReplicator[T Object](ctx context.Context, config ReplicatorConfig, adapter Adapter[T]) *TypedReplicator[T] {
+	repl := &TypedReplicator[T]{
+		GenericReplicator: NewGenericReplicator(ctx, config),
+		Adapter:           adapter,
+	}
+	repl.UpdateFuncs = UpdateFuncs{
+		ReplicateDataFrom:        repl.ReplicateDataFrom,
+		ReplicateObjectTo:        repl.ReplicateObjectTo,
+		DeleteReplicatedResource: repl.DeleteReplicatedResource,
+	}
+
+	return repl
+}
+
+// ReplicateDataFrom takes a source object and copies over data to target object
+func (r *TypedReplicator[T]) ReplicateDataFrom(sourceObj interface{}, targetObj interface{}) error {
+	source := sourceObj.(T)
+	target := targetObj.(T)
+
+	logger := log.
+		WithField("kind", r.Kind).
+		WithField("source", MustGetKey(source)).
+		WithField("target", MustGetKey(target))
+
+	if !IsManagedBy(target) {
+		logger.Debugf("target is not managed and will not be synced")
+		return nil
+	}
+
+	if IgnoresDrift(target) {
+		logger.Debugf("target ignores drift and will not be synced")
+		return nil
+	}
+
+	prepared := r.Adapter.Prepare(target.GetNamespace(), source)
+	if err := InvalidHosts(prepared); err != nil {
+		return err
+	}
+
+	if ReplicatedHashEqual(prepared, target) {
+		if !r.Adapter.IsDrifted(prepared, target) {
+			logger.Debugf("target is already up-to-date")
+			return nil
+		}
+
+		logger.Infof("target has drifted from %s, restoring", MustGetKey(source))
+	}
+
+	if r.ServerSideApply {
+		return r.apply(prepared)
+	}
+
+	// the update is conditional on the observed target, custom resources can not be updated unconditionally
+	prepared.SetResourceVersion(target.GetResourceVersion())
+	if _, err := r.Adapter.ResourceClient(target.GetNamespace()).Update(r.Context, prepared, UpdateOptions()); err != nil {
+		return errors.Wrapf(err, "Failed updating target %s", MustGetKey(prepared))
+	}
+	return nil
+}
+
+// ReplicateObjectTo copies the whole object to target namespace
+func (r *TypedReplicator[T]) ReplicateObjectTo(sourceObj interface{}, targetNamespace *v1.Namespace) error {
+	source := sourceObj.(T)
+	sourceKey := MustGetKey(source)
+	targetLocation := fmt.Sprintf("%s/%s", targetNamespace.Name, source.GetName())
+
+	logger := log.WithField("source", sourceKey).WithField("target", targetLocation).WithField("kind", r.Kind)
+	logger.Infof("Replicating %s to %s", sourceKey, targetNamespace.Name)
+
+	client := r.Adapter.ResourceClient(targetNamespace.Name)
+	if targetResource, err := client.Get(r.Context, source.GetName(), metav1.GetOptions{}); err == nil {
+		return r.ReplicateDataFrom(source, targetResource)
+	}
+
+	prepared := r.Adapter.Prepare(targetNamespace.Name, source)
+	if err := InvalidHosts(prepared); err != nil {
+		return err
+	}
+
+	if r.ServerSideApply {
+		return r.apply(prepared)
+	}
+
+	if _, err := client.Create(r.Context, prepared, CreateOptions()); err != nil {
+		return errors.Wrapf(err, "Failed creating target %s", MustGetKey(prepared))
+	}
+	return nil
+}
+
+// apply server-side applies the prepared resource, taking ownership of only the fields set by this controller
+func (r *TypedReplicator[T]) apply(prepared T) error {
+	data, err := PrepareApplyPatch(prepared, r.Adapter.GroupVersionKind())
+	if err != nil {
+		return errors.Wrapf(err, "Failed preparing target %s", MustGetKey(prepared))
+	}
+
+	if _, err := r.Adapter.ResourceClient(prepared.GetNamespace()).Patch(r.Context, prepared.GetName(), types.ApplyPatchType, data, ApplyOptions()); err != nil {
+		return errors.Wrapf(err, "Failed applying target %s", MustGetKey(prepared))
+	}
+	return nil
+}
+
+// DeleteReplicatedResource deletes a resource replicated by ReplicateTo annotation or alongside a referencing resource
+func (r *TypedReplicator[T]) DeleteReplicatedResource(targetResource interface{}) error {
+	target := targetResource.(T)
+
+	if !IsManagedBy(target) {
+		log.WithField("kind", r.Kind).WithField("target", MustGetKey(target)).
+			Debugf("target is not managed and will not be deleted")
+		return nil
+	}
+
+	return r.Adapter.ResourceClient(target.GetNamespace()).Delete(r.Context, target.GetName(), metav1.DeleteOptions{})
+}
@@ -0,0 +1,194 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type configMapAdapter struct {
+	client kubernetes.Interface
+
+	// invalidHost is recorded as an invalid host of every prepared replica, if set
+	invalidHost string
+}
+
+func (a *configMapAdapter) ResourceClient(namespace string) Client[*v1.ConfigMap] {
+	return a.client.CoreV1().ConfigMaps(namespace)
+}
+
+func (a *configMapAdapter) GroupVersionKind() schema.GroupVersionKind {
+	return v1.SchemeGroupVersion.WithKind("ConfigMap")
+}
+
+func (a *configMapAdapter) Prepare(namespace string, source *v1.ConfigMap) *v1.ConfigMap {
+	prepared := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        source.Name,
+			Namespace:   namespace,
+			Labels:      PrepareLabels(source.ObjectMeta),
+			Annotations: PrepareAnnotations(source.ObjectMeta),
+		},
+		Data: source.Data,
+	}
+
+	if len(a.invalidHost) > 0 {
+		SetHostMapping(prepared, &HostMapping{Invalid: []error{ValidateHost(a.invalidHost)}})
+	}
+
+	MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+func (a *configMapAdapter) IsDrifted(prepared *v1.ConfigMap, target *v1.ConfigMap) bool {
+	return MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Data, target.Data)
+}
+
+func newTestTypedReplicator(client *fake.Clientset) *TypedReplicator[*v1.ConfigMap] {
+	factory := informers.NewSharedInformerFactory(client, 0)
+
+	return NewTypedReplicator[*v1.ConfigMap](context.Background(), ReplicatorConfig{
+		Kind:             "ConfigMap",
+		Client:           client,
+		Informer:         factory.Core().V1().ConfigMaps().Informer(),
+		NamespaceWatcher: NewNamespaceWatcher(factory.Core().V1().Namespaces()),
+	}, &configMapAdapter{client: client})
+}
+
+func writeActions(client *fake.Clientset) (verbs []string) {
+	for _, action := range client.Actions() {
+		if action.GetVerb() != "get" {
+			verbs = append(verbs, action.GetVerb())
+		}
+	}
+	return verbs
+}
+
+func Test_TypedReplicator_ReplicateObjectTo(t *testing.T) {
+	source := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Data:       map[string]string{"key": "value"},
+	}
+	target := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-a"}}
+
+	client := fake.NewSimpleClientset()
+	repl := newTestTypedReplicator(client)
+
+	// created when missing
+	assert.NoError(t, repl.ReplicateObjectTo(source, target))
+	replica, err := client.CoreV1().ConfigMaps("feature-a").Get(context.Background(), "config", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, IsManagedBy(replica))
+	assert.Equal(t, source.Data, replica.Data)
+	assert.Equal(t, []string{"create"}, writeActions(client))
+
+	// not written when up-to-date
+	client.ClearActions()
+	assert.NoError(t, repl.ReplicateObjectTo(source, target))
+	assert.Empty(t, writeActions(client))
+
+	// updated when the source changes
+	client.ClearActions()
+	source.Data = map[string]string{"key": "changed"}
+	assert.NoError(t, repl.ReplicateObjectTo(source, target))
+	assert.Equal(t, []string{"update"}, writeActions(client))
+	replica, _ = client.CoreV1().ConfigMaps("feature-a").Get(context.Background(), "config", metav1.GetOptions{})
+	assert.Equal(t, "changed", replica.Data["key"])
+
+	// restored when the target drifted
+	client.ClearActions()
+	replica.Data["key"] = "drifted"
+	_, err = client.CoreV1().ConfigMaps("feature-a").Update(context.Background(), replica, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, repl.ReplicateObjectTo(source, target))
+	replica, _ = client.CoreV1().ConfigMaps("feature-a").Get(context.Background(), "config", metav1.GetOptions{})
+	assert.Equal(t, "changed", replica.Data["key"])
+
+	// left alone when the target ignores drift
+	replica.Annotations[IgnoreDrift] = "true"
+	replica.Data["key"] = "drifted"
+	_, err = client.CoreV1().ConfigMaps("feature-a").Update(context.Background(), replica, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	client.ClearActions()
+	assert.NoError(t, repl.ReplicateObjectTo(source, target))
+	assert.Empty(t, writeActions(client))
+}
+
+func Test_TypedReplicator_ReplicateObjectTo_Unmanaged(t *testing.T) {
+	source := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Data:       map[string]string{"key": "value"},
+	}
+	unmanaged := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "feature-a"},
+		Data:       map[string]string{"key": "own"},
+	}
+
+	client := fake.NewSimpleClientset(unmanaged)
+	repl := newTestTypedReplicator(client)
+
+	assert.NoError(t, repl.ReplicateObjectTo(source, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-a"}}))
+	assert.Empty(t, writeActions(client))
+
+	assert.NoError(t, repl.DeleteReplicatedResource(unmanaged))
+	assert.Empty(t, writeActions(client))
+}
+
+func Test_TypedReplicator_DeleteReplicatedResource(t *testing.T) {
+	replica := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name:      "config",
+		Namespace: "feature-a",
+		Labels:    map[string]string{ManagedByLabelKey: ManagedByLabelValue},
+	}}
+
+	client := fake.NewSimpleClientset(replica)
+	repl := newTestTypedReplicator(client)
+
+	assert.NoError(t, repl.DeleteReplicatedResource(replica))
+	assert.Equal(t, []string{"delete"}, writeActions(client))
+}
+
+func Test_TypedReplicator_ServerSideApply(t *testing.T) {
+	source := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Data:       map[string]string{"key": "value"},
+	}
+
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		assert.Equal(t, types.ApplyPatchType, action.(k8stesting.PatchAction).GetPatchType())
+		return true, &v1.ConfigMap{}, nil
+	})
+	repl := newTestTypedReplicator(client)
+	repl.ServerSideApply = true
+
+	assert.NoError(t, repl.ReplicateObjectTo(source, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-a"}}))
+	assert.Equal(t, []string{"patch"}, writeActions(client))
+}
+
+func Test_TypedReplicator_InvalidHosts(t *testing.T) {
+	source := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"},
+		Data:       map[string]string{"key": "value"},
+	}
+	target := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-a"}}
+
+	client := fake.NewSimpleClientset()
+	repl := newTestTypedReplicator(client)
+	repl.Adapter.(*configMapAdapter).invalidHost = "feature-a..example.com"
+
+	// replicas with invalid hosts are not written, so that their replication is retried
+	assert.ErrorContains(t, repl.ReplicateObjectTo(source, target), "invalid host feature-a..example.com")
+	assert.Empty(t, writeActions(client))
+}
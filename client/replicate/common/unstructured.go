@@ -0,0 +1,104 @@
+package common
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// dynamicClient adapts the dynamic client of a resource to the Client of a TypedReplicator of unstructured objects
+type dynamicClient struct {
+	resource dynamic.ResourceInterface
+}
+
+// NewDynamicClient wraps the dynamic client of a resource, scoped to a single namespace, in a Client
+func NewDynamicClient(resource dynamic.ResourceInterface) Client[*unstructured.Unstructured] {
+	return dynamicClient{resource: resource}
+}
+
+func (c dynamicClient) Get(ctx context.Context, name string, opts metav1.GetOptions) (*unstructured.Unstructured, error) {
+	return c.resource.Get(ctx, name, opts)
+}
+
+func (c dynamicClient) Create(ctx context.Context, obj *unstructured.Unstructured, opts metav1.CreateOptions) (*unstructured.Unstructured, error) {
+	return c.resource.Create(ctx, obj, opts)
+}
+
+func (c dynamicClient) Update(ctx context.Context, obj *unstructured.Unstructured, opts metav1.UpdateOptions) (*unstructured.Unstructured, error) {
+	return c.resource.Update(ctx, obj, opts)
+}
+
+func (c dynamicClient) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	return c.resource.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+func (c dynamicClient) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.resource.Delete(ctx, name, opts)
+}
+
+// PrepareUnstructured copies all fields of the source except for its metadata and status into a replica of the
+// provided kind in the namespace. The ReplicatedHashAnnotation is not set, so that the replica can still be modified.
+func PrepareUnstructured(namespace string, gvk schema.GroupVersionKind, source *unstructured.Unstructured) *unstructured.Unstructured {
+	sourceMeta := metav1.ObjectMeta{
+		Namespace:       source.GetNamespace(),
+		Name:            source.GetName(),
+		Labels:          source.GetLabels(),
+		Annotations:     source.GetAnnotations(),
+		OwnerReferences: source.GetOwnerReferences(),
+	}
+
+	prepared := &unstructured.Unstructured{Object: UnstructuredContent(source)}
+	prepared.SetGroupVersionKind(gvk)
+	prepared.SetName(source.GetName())
+	prepared.SetNamespace(namespace)
+	prepared.SetLabels(PrepareLabels(sourceMeta))
+	prepared.SetAnnotations(PrepareAnnotations(sourceMeta))
+	prepared.SetOwnerReferences(PrepareOwnerReferences(sourceMeta))
+
+	return prepared
+}
+
+// UnstructuredContent deep copies all fields of the object except for its metadata and status
+func UnstructuredContent(obj *unstructured.Unstructured) map[string]interface{} {
+	copied := make(map[string]interface{}, len(obj.Object))
+	for key, value := range obj.Object {
+		if key == "metadata" || key == "status" {
+			continue
+		}
+		copied[key] = runtime.DeepCopyJSONValue(value)
+	}
+
+	return copied
+}
+
+// UnstructuredDrifted compares the metadata and all fields except for the status of the target to the prepared object
+func UnstructuredDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return MetadataDrifted(prepared, target) ||
+		!equality.Semantic.DeepEqual(UnstructuredContent(prepared), UnstructuredContent(target))
+}
+
+// PrepareHost rewrites a hostname for the namespace with the TopLevelDomain annotation of the source or the default
+// ingress hostname, if set. Wildcards and short names without a domain, such as the names of Services, are kept as-is.
+// Shortened hosts are recorded in the mapping like PrepareMappedTLD.
+func (r *GenericReplicator) PrepareHost(namespace string, source metav1.Object, host string, mapping *HostMapping) string {
+	if host == "*" || !strings.Contains(host, ".") {
+		return host
+	}
+
+	if tld, ok := source.GetAnnotations()[TopLevelDomain]; ok {
+		return r.PrepareMappedTLD(namespace, source, tld, mapping)
+	}
+
+	if r.HasDefaultIngressHostname() {
+		return r.PrepareMappedTLD(namespace, source, r.DefaultIngressHostname, mapping)
+	}
+
+	return r.PrepareMappedTLD(namespace, source, host, mapping)
+}
@@ -0,0 +1,224 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MaxRetries is the number of times a resource will be retried before it is dropped out of the queue.
+// With the default rate limiter, the delay between retries grows exponentially from 5ms up to 1000s.
+const MaxRetries = 15
+
+// DefaultWorkers is the number of workers used when none are configured.
+const DefaultWorkers = 2
+
+// enqueue adds the key of the provided resource to the work queue
+func (r *GenericReplicator) enqueue(obj interface{}) {
+	r.Queue.Add(MustGetKey(obj))
+}
+
+// enqueueSource adds the key of the resource a replica was replicated from to the work queue
+func (r *GenericReplicator) enqueueSource(target metav1.Object) {
+	sourceKey, ok := target.GetAnnotations()[ReplicatedFromAnnotation]
+	if !ok || !r.Index.Has(sourceKey) {
+		return
+	}
+
+	r.Queue.Add(sourceKey)
+}
+
+// runWorker processes items from the work queue until it is shut down
+func (r *GenericReplicator) runWorker() {
+	for r.processNextItem() {
+	}
+}
+
+// processNextItem waits for the next key on the work queue and syncs it
+func (r *GenericReplicator) processNextItem() bool {
+	key, quit := r.Queue.Get()
+	if quit {
+		return false
+	}
+	defer r.Queue.Done(key)
+
+	// only in-flight items are finished once shutting down, queued items are dropped
+	if r.Queue.ShuttingDown() {
+		log.WithField("kind", r.Kind).WithField("resource", key).Debugf("dropping queued %s %v on shutdown", r.Kind, key)
+		return false
+	}
+
+	err := r.syncResource(key.(string))
+	r.handleErr(err, key)
+
+	return true
+}
+
+// handleErr requeues failed keys with an exponential backoff until MaxRetries is reached
+func (r *GenericReplicator) handleErr(err error, key interface{}) {
+	if err == nil {
+		r.Queue.Forget(key)
+		return
+	}
+
+	logger := log.WithField("kind", r.Kind).WithField("resource", key).WithError(err)
+
+	if r.Queue.NumRequeues(key) < MaxRetries {
+		logger.Warnf("error syncing %s %v, retrying", r.Kind, key)
+		r.Queue.AddRateLimited(key)
+		return
+	}
+
+	r.Queue.Forget(key)
+	logger.Errorf("dropping %s %v out of the queue after %d retries", r.Kind, key, MaxRetries)
+}
+
+// syncResource reconciles the resource stored under the provided key with replicateKey and the ReconcileSource
+// update function, if set
+func (r *GenericReplicator) syncResource(key string) error {
+	defer r.notifyReconciled(key)
+
+	err := r.replicateKey(key)
+	if r.UpdateFuncs.ReconcileSource != nil {
+		if reconcileErr := r.UpdateFuncs.ReconcileSource(key); reconcileErr != nil {
+			err = multierror.Append(err, reconcileErr)
+		}
+	}
+
+	return err
+}
+
+// replicateKey replicates the resource stored under the provided key into all namespaces selected by its
+// annotations or by resources referencing it, and deletes replicas from namespaces that are no longer selected.
+func (r *GenericReplicator) replicateKey(key string) error {
+	obj, exists, err := r.Store.GetByKey(key)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s %s from store", r.Kind, key)
+	}
+
+	if !exists {
+		return r.deleteReplicas(key)
+	}
+
+	objectMeta := MustGetObject(obj)
+	if IsManagedBy(objectMeta) {
+		return nil
+	}
+
+	referencing, err := r.ListReferencingNamespaces(objectMeta)
+	if err != nil {
+		return errors.Wrap(err, "error while listing referencing namespaces")
+	}
+
+	if !HasReplicationAnnotations(objectMeta) {
+		if len(referencing) == 0 {
+			return r.deleteReplicas(key)
+		}
+
+		r.Index.SetRule(key, ReplicationRule{})
+		return r.replicateResource(obj, referencing)
+	}
+
+	rule, err := NewReplicationRule(objectMeta)
+	if err != nil {
+		// an invalid selector will not become valid by retrying
+		log.WithField("kind", r.Kind).WithField("resource", key).WithError(err).Error("invalid replication rule")
+	}
+	r.Index.SetRule(key, rule)
+
+	namespaces, err := r.ListRuleNamespaces(rule)
+	if err != nil {
+		return errors.Wrap(err, "error while listing namespaces")
+	}
+
+	return r.replicateResource(obj, mergeNamespaces(namespaces, referencing))
+}
+
+// notifyReconciled calls the functions registered with OnReconciled
+func (r *GenericReplicator) notifyReconciled(key string) {
+	for _, reconciled := range r.reconciled {
+		reconciled(key)
+	}
+}
+
+// replicateResource replicates the resource into the provided namespaces and deletes stale replicas
+func (r *GenericReplicator) replicateResource(obj interface{}, namespaces []*v1.Namespace) (err error) {
+	sourceKey := MustGetKey(obj)
+
+	selected := make(map[string]struct{})
+	var replicated int
+	for _, namespace := range namespaces {
+		selected[namespace.Name] = struct{}{}
+		if innerErr := r.UpdateFuncs.ReplicateObjectTo(obj, namespace); innerErr != nil {
+			err = multierror.Append(err, errors.Wrapf(innerErr, "Failed to replicate %s %s -> %s",
+				r.Kind, sourceKey, namespace.Name,
+			))
+			continue
+		}
+
+		replicated++
+		r.Index.AddTarget(sourceKey, namespace.Name)
+	}
+
+	if err != nil {
+		err = errors.Wrapf(err, "Replicated %s to %d out of %d namespaces", sourceKey, replicated, len(selected))
+	}
+
+	for _, namespace := range r.Index.Targets(sourceKey) {
+		if _, ok := selected[namespace]; ok {
+			continue
+		}
+
+		if innerErr := r.deleteReplica(sourceKey, namespace); innerErr != nil {
+			err = multierror.Append(err, innerErr)
+		}
+	}
+
+	return
+}
+
+// deleteReplicas deletes all replicas of the source and removes it from the index
+func (r *GenericReplicator) deleteReplicas(sourceKey string) (err error) {
+	for _, namespace := range r.Index.Targets(sourceKey) {
+		if innerErr := r.deleteReplica(sourceKey, namespace); innerErr != nil {
+			err = multierror.Append(err, innerErr)
+		}
+	}
+
+	if err == nil {
+		r.Index.Delete(sourceKey)
+		r.hostTemplates.Delete(sourceKey)
+	}
+
+	return
+}
+
+// deleteReplica deletes the replica of the source from the provided namespace
+func (r *GenericReplicator) deleteReplica(sourceKey, namespace string) error {
+	_, name, err := cache.SplitMetaNamespaceKey(sourceKey)
+	if err != nil {
+		return err
+	}
+
+	targetKey := fmt.Sprintf("%s/%s", namespace, name)
+	target, exists, err := r.Store.GetByKey(targetKey)
+	if err != nil {
+		return errors.Wrapf(err, "could not get %s %s from store", r.Kind, targetKey)
+	}
+
+	if exists {
+		log.WithField("kind", r.Kind).WithField("source", sourceKey).Infof("Deleting %s: %s", r.Kind, targetKey)
+		if err := r.UpdateFuncs.DeleteReplicatedResource(target); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "Could not delete resource %s", targetKey)
+		}
+	}
+
+	r.Index.RemoveTarget(sourceKey, namespace)
+	return nil
+}
@@ -0,0 +1,79 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func newTestQueueReplicator() *GenericReplicator {
+	return &GenericReplicator{
+		ReplicatorConfig: ReplicatorConfig{Kind: "Service"},
+		Queue:            workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(0, 0)),
+	}
+}
+
+func Test_HandleErr_Retries(t *testing.T) {
+	repl := newTestQueueReplicator()
+	defer repl.Queue.ShutDown()
+
+	key := "default/nginx"
+	for i := 1; i <= MaxRetries; i++ {
+		repl.handleErr(errors.New("failed"), key)
+		assert.Equal(t, i, repl.Queue.NumRequeues(key))
+	}
+
+	// the key is dropped once MaxRetries is reached
+	repl.handleErr(errors.New("failed"), key)
+	assert.Equal(t, 0, repl.Queue.NumRequeues(key))
+}
+
+func Test_HandleErr_Forget(t *testing.T) {
+	repl := newTestQueueReplicator()
+	defer repl.Queue.ShutDown()
+
+	key := "default/nginx"
+	repl.handleErr(errors.New("failed"), key)
+	repl.handleErr(errors.New("failed"), key)
+	assert.Equal(t, 2, repl.Queue.NumRequeues(key))
+
+	repl.handleErr(nil, key)
+	assert.Equal(t, 0, repl.Queue.NumRequeues(key))
+}
+
+func Test_SyncResource_ReconcileSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset(
+		testNamespace("feature-0", nil),
+		testNamespace("feature-1", nil),
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:        "service-0",
+			Namespace:   "default",
+			Annotations: map[string]string{ReplicateTo: "feature-.*"},
+		}},
+	)
+	repl, factory := newTestReplicator(ctx, client)
+	defer repl.Queue.ShutDown()
+
+	var reconciled []string
+	repl.UpdateFuncs.ReconcileSource = func(sourceKey string) error {
+		reconciled = append(reconciled, sourceKey)
+		return errors.New("failed")
+	}
+
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	// the source is reconciled once, replicas are created regardless of its error
+	assert.EqualError(t, repl.syncResource("default/service-0"), "1 error occurred:\n\t* failed\n\n")
+	assert.Equal(t, []string{"default/service-0"}, reconciled)
+	assert.ElementsMatch(t, []string{"feature-0", "feature-1"}, repl.Index.Targets("default/service-0"))
+}
@@ -0,0 +1,81 @@
+package configmap
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1.ConfigMap]
+}
+
+// NewReplicator creates a new configmap replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer coreinformers.ConfigMapInformer) common.Replicator {
+	config.Kind = "ConfigMap"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1.ConfigMap](ctx, config, &repl)
+
+	return &repl
+}
+
+// ResourceClient returns the ConfigMap client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1.ConfigMap] {
+	return r.Client.CoreV1().ConfigMaps(namespace)
+}
+
+// GroupVersionKind is the kind of ConfigMaps
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1.SchemeGroupVersion.WithKind("ConfigMap")
+}
+
+// Prepare builds the replica of the source ConfigMap with its hosts rewritten for the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1.ConfigMap) *v1.ConfigMap {
+	shortened := common.NewHostMapping()
+	prepared := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Data:       r.prepareData(namespace, source, shortened),
+		BinaryData: source.BinaryData,
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// prepareData rewrites the hosts listed in the RewriteHosts annotation to their namespaced equivalent in all data values
+func (r *Replicator) prepareData(namespace string, source *v1.ConfigMap, shortened *common.HostMapping) map[string]string {
+	hosts := common.StringToList(source.Annotations[common.RewriteHosts])
+	if len(hosts) == 0 {
+		return source.Data
+	}
+
+	data := make(map[string]string, len(source.Data))
+	for key, value := range source.Data {
+		data[key] = common.ReplaceHostsFunc(value, hosts, func(host string) string {
+			return r.PrepareMappedTLD(namespace, source, host, shortened)
+		})
+	}
+
+	return data
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1.ConfigMap, target *v1.ConfigMap) bool {
+	return common.MetadataDrifted(prepared, target) ||
+		!equality.Semantic.DeepEqual(prepared.Data, target.Data) ||
+		!equality.Semantic.DeepEqual(prepared.BinaryData, target.BinaryData)
+}
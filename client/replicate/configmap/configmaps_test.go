@@ -0,0 +1,87 @@
+package configmap
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestReplicator() *Replicator {
+	return &Replicator{TypedReplicator: &common.TypedReplicator[*v1.ConfigMap]{
+		GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "ConfigMap"}},
+	}}
+}
+
+func testConfigMap(annotations map[string]string) *v1.ConfigMap {
+	annotations[common.ReplicateTo] = "feature-.*"
+
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend", Namespace: "default", Annotations: annotations},
+		Data: map[string]string{
+			"api.example.com": "https://api.example.com/v1",
+			"config.json":     `{"app": "app.example.com:8080", "cdn": "cdn.example.com"}`,
+		},
+		BinaryData: map[string][]byte{"logo.png": {0x89, 0x50, 0x4e, 0x47}},
+	}
+}
+
+func Test_Replicator_Prepare(t *testing.T) {
+	r := newTestReplicator()
+	source := testConfigMap(map[string]string{common.RewriteHosts: "api.example.com, app.example.com"})
+	prepared := r.Prepare("feature-a", source)
+
+	assert.Equal(t, "feature-a", prepared.Namespace)
+	assert.True(t, common.IsManagedBy(prepared))
+
+	// keys are left untouched, even if they match a host
+	assert.Equal(t, map[string]string{
+		"api.example.com": "https://feature-a.example.com/v1",
+		"config.json":     `{"app": "feature-a.example.com:8080", "cdn": "cdn.example.com"}`,
+	}, prepared.Data)
+	assert.Equal(t, source.BinaryData, prepared.BinaryData)
+
+	// the source is not modified
+	assert.Equal(t, "https://api.example.com/v1", source.Data["api.example.com"])
+}
+
+func Test_Replicator_Prepare_HostTemplate(t *testing.T) {
+	r := newTestReplicator()
+	source := testConfigMap(map[string]string{
+		common.RewriteHosts: "api.example.com",
+		common.HostTemplate: "{{ .Namespace }}--{{ .Host.FirstLabel }}.preview.example.com",
+	})
+
+	prepared := r.Prepare("feature-a", source)
+	assert.Equal(t, "https://feature-a--api.preview.example.com/v1", prepared.Data["api.example.com"])
+}
+
+func Test_Replicator_Prepare_NoRewriteHosts(t *testing.T) {
+	r := newTestReplicator()
+	source := testConfigMap(map[string]string{})
+
+	prepared := r.Prepare("feature-a", source)
+	assert.Equal(t, source.Data, prepared.Data)
+	assert.Equal(t, source.BinaryData, prepared.BinaryData)
+}
+
+func Test_Replicator_IsDrifted(t *testing.T) {
+	r := newTestReplicator()
+	prepared := r.Prepare("feature-a", testConfigMap(map[string]string{common.RewriteHosts: "api.example.com"}))
+
+	assert.False(t, r.IsDrifted(prepared, prepared.DeepCopy()))
+
+	target := prepared.DeepCopy()
+	target.Data["config.json"] = "{}"
+	assert.True(t, r.IsDrifted(prepared, target))
+
+	target = prepared.DeepCopy()
+	target.BinaryData = nil
+	assert.True(t, r.IsDrifted(prepared, target))
+
+	target = prepared.DeepCopy()
+	delete(target.Annotations, common.ReplicatedFromAnnotation)
+	assert.True(t, r.IsDrifted(prepared, target))
+}
@@ -0,0 +1,77 @@
+package dynamic
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// Config lists the resources that are replicated with the dynamic client
+type Config struct {
+	Resources []Resource `json:"resources"`
+}
+
+// Resource configures the replication of an arbitrary kind with the dynamic client
+type Resource struct {
+	Group    string `json:"group,omitempty"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	Kind     string `json:"kind"`
+
+	// HostPaths are JSONPath expressions selecting the hostnames that are rewritten for the target namespace,
+	// such as "{.spec.hosts[*]}"
+	HostPaths []string `json:"hostPaths,omitempty"`
+}
+
+// LoadConfig reads and validates the dynamic resource configuration from the provided YAML file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read dynamic resource config %s", path)
+	}
+
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates the dynamic resource configuration
+func ParseConfig(data []byte) (*Config, error) {
+	config := new(Config)
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, errors.Wrap(err, "failed to parse dynamic resource config")
+	}
+
+	for _, resource := range config.Resources {
+		if err := resource.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// Validate checks that the resource is fully specified and that its host paths can be parsed
+func (r Resource) Validate() error {
+	if len(r.Version) == 0 || len(r.Resource) == 0 || len(r.Kind) == 0 {
+		return errors.Errorf("dynamic resource %s requires a version, resource and kind", r.GroupVersionResource())
+	}
+
+	for _, path := range r.HostPaths {
+		if _, err := ParseHostPath(path); err != nil {
+			return errors.Wrapf(err, "invalid host path of dynamic resource %s", r.GroupVersionResource())
+		}
+	}
+
+	return nil
+}
+
+// GroupVersionResource is the resource served by the API
+func (r Resource) GroupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// GroupVersionKind is the kind of the resource
+func (r Resource) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind}
+}
@@ -0,0 +1,114 @@
+package dynamic
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// wildcard is the index of a "[*]" path segment
+const wildcard = -1
+
+// HostPath is a parsed JSONPath expression selecting string values of an unstructured object.
+// Only the subset of JSONPath needed to address fields is supported: fields (".spec.hosts"),
+// indices ("[0]") and wildcards ("[*]"), optionally surrounded by braces and prefixed with "$".
+type HostPath []pathSegment
+
+type pathSegment struct {
+	field   string
+	index   int
+	isIndex bool
+}
+
+// ParseHostPath parses a JSONPath expression such as "{.spec.tls[*].match[*].sniHosts[*]}"
+func ParseHostPath(expression string) (HostPath, error) {
+	expr := strings.TrimSpace(expression)
+	expr = strings.TrimSuffix(strings.TrimPrefix(expr, "{"), "}")
+	expr = strings.TrimPrefix(expr, "$")
+
+	if len(expr) == 0 {
+		return nil, errors.Errorf("empty JSONPath expression %q", expression)
+	}
+
+	var path HostPath
+	for len(expr) > 0 {
+		switch expr[0] {
+		case '.':
+			end := strings.IndexAny(expr[1:], ".[")
+			if end < 0 {
+				end = len(expr) - 1
+			}
+
+			field := expr[1 : end+1]
+			if len(field) == 0 || field == "*" || strings.ContainsAny(field, "]()@?'\"") {
+				return nil, errors.Errorf("unsupported field %q in JSONPath expression %q", field, expression)
+			}
+
+			path = append(path, pathSegment{field: field})
+			expr = expr[end+1:]
+		case '[':
+			end := strings.IndexByte(expr, ']')
+			if end < 0 {
+				return nil, errors.Errorf("unterminated index in JSONPath expression %q", expression)
+			}
+
+			segment := pathSegment{isIndex: true, index: wildcard}
+			if value := expr[1:end]; value != "*" {
+				index, err := strconv.Atoi(value)
+				if err != nil || index < 0 {
+					return nil, errors.Errorf("unsupported index %q in JSONPath expression %q", value, expression)
+				}
+				segment.index = index
+			}
+
+			path = append(path, segment)
+			expr = expr[end+1:]
+		default:
+			return nil, errors.Errorf("unsupported JSONPath expression %q, expected \".\" or \"[\"", expression)
+		}
+	}
+
+	return path, nil
+}
+
+// Rewrite replaces every string value selected by the path with the result of the rewrite function.
+// Missing fields and values that are not strings are ignored.
+func (p HostPath) Rewrite(obj map[string]interface{}, rewrite func(string) string) {
+	rewritePath(obj, p, rewrite)
+}
+
+// rewritePath walks the value along the path and returns the rewritten value
+func rewritePath(value interface{}, path HostPath, rewrite func(string) string) interface{} {
+	if len(path) == 0 {
+		if s, ok := value.(string); ok {
+			return rewrite(s)
+		}
+		return value
+	}
+
+	segment, rest := path[0], path[1:]
+	if !segment.isIndex {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+
+		if field, ok := m[segment.field]; ok {
+			m[segment.field] = rewritePath(field, rest, rewrite)
+		}
+		return m
+	}
+
+	list, ok := value.([]interface{})
+	if !ok {
+		return value
+	}
+
+	for i := range list {
+		if segment.index == wildcard || segment.index == i {
+			list[i] = rewritePath(list[i], rest, rewrite)
+		}
+	}
+	return list
+}
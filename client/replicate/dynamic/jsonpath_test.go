@@ -0,0 +1,67 @@
+package dynamic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseHostPath(t *testing.T) {
+	path, err := ParseHostPath("{.spec.tls[*].match[0].sniHosts[*]}")
+	assert.NoError(t, err)
+	assert.Equal(t, HostPath{
+		{field: "spec"},
+		{field: "tls"},
+		{isIndex: true, index: wildcard},
+		{field: "match"},
+		{isIndex: true, index: 0},
+		{field: "sniHosts"},
+		{isIndex: true, index: wildcard},
+	}, path)
+
+	path, err = ParseHostPath("$.spec.hosts")
+	assert.NoError(t, err)
+	assert.Equal(t, HostPath{{field: "spec"}, {field: "hosts"}}, path)
+
+	for _, expression := range []string{"", "{}", "spec.hosts", ".spec..hosts", ".spec.hosts[", ".spec.hosts[-1]", ".spec.*", ".spec.hosts[?(@.name)]"} {
+		_, err := ParseHostPath(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func Test_HostPath_Rewrite(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"hosts": []interface{}{"app.example.com", "reviews"},
+			"tls": []interface{}{
+				map[string]interface{}{
+					"match": []interface{}{
+						map[string]interface{}{"sniHosts": []interface{}{"tls.example.com", int64(443)}},
+					},
+				},
+				map[string]interface{}{"route": "no match"},
+			},
+		},
+	}
+
+	for _, expression := range []string{"{.spec.hosts[*]}", "{.spec.tls[*].match[*].sniHosts[*]}", "{.spec.missing[*]}", "{.spec.hosts.name}"} {
+		path, err := ParseHostPath(expression)
+		assert.NoError(t, err)
+		path.Rewrite(obj, strings.ToUpper)
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"hosts": []interface{}{"APP.EXAMPLE.COM", "REVIEWS"},
+			"tls": []interface{}{
+				map[string]interface{}{
+					"match": []interface{}{
+						map[string]interface{}{"sniHosts": []interface{}{"TLS.EXAMPLE.COM", int64(443)}},
+					},
+				},
+				map[string]interface{}{"route": "no match"},
+			},
+		},
+	}, obj)
+}
@@ -0,0 +1,72 @@
+package dynamic
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+	Resource  Resource
+	hostPaths []HostPath
+}
+
+// NewReplicator creates a new replicator for the configured resource, which is replicated as unstructured objects
+// with the dynamic client
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, resource Resource, informer informers.GenericInformer) (common.Replicator, error) {
+	config.Kind = resource.Kind
+	config.Informer = informer.Informer()
+
+	repl := Replicator{
+		Resource: resource,
+	}
+
+	for _, expression := range resource.HostPaths {
+		path, err := ParseHostPath(expression)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid host path of dynamic resource %s", resource.GroupVersionResource())
+		}
+		repl.hostPaths = append(repl.hostPaths, path)
+	}
+
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+
+	return &repl, nil
+}
+
+// ResourceClient returns the dynamic client of the configured resource in the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(r.Resource.GroupVersionResource()).Namespace(namespace))
+}
+
+// GroupVersionKind is the configured kind
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return r.Resource.GroupVersionKind()
+}
+
+// Prepare copies all fields of the source except for its metadata and status, and rewrites the hostnames
+// selected by the host paths
+func (r *Replicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.Resource.GroupVersionKind(), source)
+	shortened := common.NewHostMapping()
+
+	for _, path := range r.hostPaths {
+		path.Rewrite(prepared.Object, func(host string) string {
+			return r.PrepareHost(namespace, source, host, shortened)
+		})
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *Replicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
@@ -0,0 +1,97 @@
+package dynamic
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testConfig = `
+resources:
+  - group: networking.istio.io
+    version: v1beta1
+    resource: virtualservices
+    kind: VirtualService
+    hostPaths:
+      - '{.spec.hosts[*]}'
+`
+
+func Test_ParseConfig(t *testing.T) {
+	config, err := ParseConfig([]byte(testConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, []Resource{{
+		Group:     "networking.istio.io",
+		Version:   "v1beta1",
+		Resource:  "virtualservices",
+		Kind:      "VirtualService",
+		HostPaths: []string{"{.spec.hosts[*]}"},
+	}}, config.Resources)
+
+	_, err = ParseConfig([]byte("resources:\n  - version: v1\n    resource: widgets\n"))
+	assert.Error(t, err)
+
+	_, err = ParseConfig([]byte("resources:\n  - version: v1\n    resource: widgets\n    kind: Widget\n    hostPaths: ['spec']\n"))
+	assert.Error(t, err)
+
+	_, err = ParseConfig([]byte("resources:\n  - version: v1\n    resource: widgets\n    kind: Widget\n    unknown: true\n"))
+	assert.Error(t, err)
+}
+
+func Test_Prepare(t *testing.T) {
+	config, err := ParseConfig([]byte(testConfig))
+	assert.NoError(t, err)
+
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata": map[string]interface{}{
+			"name":            "reviews",
+			"namespace":       "default",
+			"resourceVersion": "42",
+			"labels":          map[string]interface{}{"app": "reviews"},
+			"annotations":     map[string]interface{}{common.ReplicateTo: "feature-.*"},
+		},
+		"spec": map[string]interface{}{
+			"hosts":    []interface{}{"reviews.example.com", "reviews", "*"},
+			"gateways": []interface{}{"mesh"},
+		},
+		"status": map[string]interface{}{"observedGeneration": int64(1)},
+	}}
+
+	r := &Replicator{
+		TypedReplicator: &common.TypedReplicator[*unstructured.Unstructured]{
+			GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "VirtualService"}},
+		},
+		Resource: config.Resources[0],
+	}
+	for _, expression := range r.Resource.HostPaths {
+		path, err := ParseHostPath(expression)
+		assert.NoError(t, err)
+		r.hostPaths = append(r.hostPaths, path)
+	}
+
+	prepared := r.Prepare("feature-a", source)
+	assert.Equal(t, "feature-a", prepared.GetNamespace())
+	assert.Equal(t, "", prepared.GetResourceVersion())
+	assert.True(t, common.IsManagedBy(prepared))
+	assert.Equal(t, "default/reviews", prepared.GetAnnotations()[common.ReplicatedFromAnnotation])
+	assert.Equal(t, "reviews", prepared.GetLabels()["app"])
+	assert.NotContains(t, prepared.Object, "status")
+	assert.Equal(t, map[string]interface{}{
+		"hosts":    []interface{}{"feature-a.example.com", "reviews", "*"},
+		"gateways": []interface{}{"mesh"},
+	}, prepared.Object["spec"])
+
+	// the source is left untouched
+	assert.Equal(t, "reviews.example.com", source.Object["spec"].(map[string]interface{})["hosts"].([]interface{})[0])
+
+	target := prepared.DeepCopy()
+	target.SetResourceVersion("7")
+	target.Object["status"] = map[string]interface{}{}
+	assert.False(t, r.IsDrifted(prepared, target))
+
+	target.Object["spec"].(map[string]interface{})["gateways"] = []interface{}{"other"}
+	assert.True(t, r.IsDrifted(prepared, target))
+}
@@ -0,0 +1,66 @@
+package gateway
+
+import (
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// PrepareHostnames replaces all hostnames of a route with the namespaced version and records shortened ones in the mapping.
+// The top-level domain annotation, or else the default hostname, replaces all hostnames with a single namespaced hostname.
+func PrepareHostnames(repl *common.GenericReplicator, namespace string, source metav1.Object, hostnames []v1beta1.Hostname, mapping *common.HostMapping) (prepared []v1beta1.Hostname) {
+	if tld, ok := source.GetAnnotations()[common.TopLevelDomain]; ok {
+		return []v1beta1.Hostname{v1beta1.Hostname(repl.PrepareMappedTLD(namespace, source, tld, mapping))}
+	}
+
+	if repl.HasDefaultIngressHostname() {
+		return []v1beta1.Hostname{v1beta1.Hostname(repl.PrepareMappedTLD(namespace, source, repl.DefaultIngressHostname, mapping))}
+	}
+
+	for _, hostname := range hostnames {
+		host := v1beta1.Hostname(repl.PrepareMappedTLD(namespace, source, string(hostname), mapping))
+		if !containsHostname(prepared, host) {
+			prepared = append(prepared, host)
+		}
+	}
+
+	return
+}
+
+// PrepareParentRefs points parent references without a namespace to the namespace of the source route,
+// so that the replicas attach to the same shared Gateway as the source
+func PrepareParentRefs(sourceNamespace string, parentRefs []v1beta1.ParentReference) []v1beta1.ParentReference {
+	prepared := make([]v1beta1.ParentReference, 0, len(parentRefs))
+	for _, parentRef := range parentRefs {
+		ref := *parentRef.DeepCopy()
+		if ref.Namespace == nil {
+			ref.Namespace = namespacePtr(sourceNamespace)
+		}
+		prepared = append(prepared, ref)
+	}
+
+	return prepared
+}
+
+// PrepareBackendRef points a backend reference without a namespace to the namespace of the source route,
+// so that the replicas keep routing to the backends of the source
+func PrepareBackendRef(sourceNamespace string, backendRef *v1beta1.BackendObjectReference) {
+	if backendRef.Namespace == nil {
+		backendRef.Namespace = namespacePtr(sourceNamespace)
+	}
+}
+
+func containsHostname(hostnames []v1beta1.Hostname, hostname v1beta1.Hostname) bool {
+	for _, h := range hostnames {
+		if h == hostname {
+			return true
+		}
+	}
+
+	return false
+}
+
+func namespacePtr(namespace string) *v1beta1.Namespace {
+	ns := v1beta1.Namespace(namespace)
+	return &ns
+}
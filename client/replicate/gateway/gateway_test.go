@@ -0,0 +1,176 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+	"sigs.k8s.io/gateway-api/pkg/client/clientset/versioned/fake"
+	gatewaylisters "sigs.k8s.io/gateway-api/pkg/client/listers/apis/v1beta1"
+)
+
+func Test_PrepareHostnames(t *testing.T) {
+	source := &v1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}}
+	hostnames := []v1beta1.Hostname{"app.example.com", "www.example.com", "api.example.com"}
+
+	repl := &common.GenericReplicator{}
+	withDefault := &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{DefaultIngressHostname: "app.default.com"}}
+
+	assert.Equal(t, []v1beta1.Hostname{"feature.example.com"}, PrepareHostnames(repl, "feature", source, hostnames, nil))
+	assert.Equal(t, []v1beta1.Hostname{"feature.default.com"}, PrepareHostnames(withDefault, "feature", source, hostnames, nil))
+	assert.Nil(t, PrepareHostnames(repl, "feature", source, nil, nil))
+
+	source.Annotations = map[string]string{common.TopLevelDomain: "*.tld.example.com"}
+	assert.Equal(t, []v1beta1.Hostname{"feature.tld.example.com"}, PrepareHostnames(withDefault, "feature", source, hostnames, nil))
+}
+
+func Test_PrepareHostnames_Shortened(t *testing.T) {
+	source := &v1beta1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}}
+	namespace := "feature-a-very-long-branch-name-that-exceeds-the-dns-label-limit"
+
+	mapping := common.NewHostMapping()
+	prepared := PrepareHostnames(&common.GenericReplicator{}, namespace, source, []v1beta1.Hostname{"app.example.com"}, mapping)
+	assert.Equal(t, []v1beta1.Hostname{"feature-a-very-long-branch-name-that-exceeds-the-dns-l-05e5f97c.example.com"}, prepared)
+	assert.Equal(t, map[string]string{namespace + ".example.com": string(prepared[0])}, mapping.Shortened)
+}
+
+func Test_PrepareParentRefs(t *testing.T) {
+	shared := v1beta1.Namespace("gateway")
+	parentRefs := []v1beta1.ParentReference{
+		{Name: "local"},
+		{Name: "shared", Namespace: &shared},
+	}
+
+	prepared := PrepareParentRefs("default", parentRefs)
+	assert.Equal(t, v1beta1.Namespace("default"), *prepared[0].Namespace)
+	assert.Equal(t, v1beta1.Namespace("gateway"), *prepared[1].Namespace)
+
+	// the source is left untouched
+	assert.Nil(t, parentRefs[0].Namespace)
+}
+
+// newTestReferenceGrants serves ReferenceGrants from a cache that is refreshed from the client by the returned function
+func newTestReferenceGrants(t *testing.T, client *fake.Clientset, route *v1beta1.HTTPRoute, namespaces ...string) (*ReferenceGrants, func()) {
+	grantIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	refresh := func() {
+		list, err := client.GatewayV1beta1().ReferenceGrants("").List(context.Background(), metav1.ListOptions{})
+		assert.NoError(t, err)
+
+		grants := make([]interface{}, 0, len(list.Items))
+		for i := range list.Items {
+			grants = append(grants, &list.Items[i])
+		}
+		assert.NoError(t, grantIndexer.Replace(grants, ""))
+	}
+	refresh()
+
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	_ = store.Add(route)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, namespace := range namespaces {
+		_ = indexer.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	}
+
+	grants := &ReferenceGrants{
+		GenericReplicator: &common.GenericReplicator{
+			ReplicatorConfig: common.ReplicatorConfig{
+				Kind:             "HTTPRoute",
+				GatewayClient:    client,
+				NamespaceWatcher: &common.NamespaceWatcher{Lister: corelisters.NewNamespaceLister(indexer)},
+			},
+			Store:   store,
+			Context: context.Background(),
+		},
+		GroupVersion: v1beta1.SchemeGroupVersion,
+		Lister:       gatewaylisters.NewReferenceGrantLister(grantIndexer),
+		BackendRefs: func(source interface{}) (refs []v1beta1.BackendObjectReference) {
+			for _, rule := range source.(*v1beta1.HTTPRoute).Spec.Rules {
+				for _, backendRef := range rule.BackendRefs {
+					refs = append(refs, backendRef.BackendObjectReference)
+				}
+			}
+			return
+		},
+	}
+
+	return grants, refresh
+}
+
+func backendRef(name string, namespace string) v1beta1.HTTPBackendRef {
+	ref := v1beta1.HTTPBackendRef{}
+	ref.Name = v1beta1.ObjectName(name)
+	if len(namespace) > 0 {
+		ref.Namespace = (*v1beta1.Namespace)(&namespace)
+	}
+	return ref
+}
+
+func Test_ReferenceGrants_Sync(t *testing.T) {
+	route := &v1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx",
+			Namespace:   "default",
+			Annotations: map[string]string{common.ReplicateTo: "feature-.*"},
+		},
+		Spec: v1beta1.HTTPRouteSpec{
+			Rules: []v1beta1.HTTPRouteRule{{
+				BackendRefs: []v1beta1.HTTPBackendRef{
+					backendRef("nginx", "default"),
+					backendRef("nginx", "default"),
+					backendRef("shared", "other"),
+					backendRef("api", "default"),
+				},
+			}},
+		},
+	}
+
+	client := fake.NewSimpleClientset()
+	grants, refresh := newTestReferenceGrants(t, client, route, "default", "feature-b", "feature-a", "production")
+	assert.NoError(t, grants.Sync("default/nginx"))
+	refresh()
+
+	grant, err := client.GatewayV1beta1().ReferenceGrants("default").Get(context.Background(), "httproute-nginx", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, common.IsManagedBy(grant))
+	assert.Equal(t, []v1beta1.ReferenceGrantFrom{
+		{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Namespace: "feature-a"},
+		{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute", Namespace: "feature-b"},
+	}, grant.Spec.From)
+
+	api, nginx := v1beta1.ObjectName("api"), v1beta1.ObjectName("nginx")
+	assert.Equal(t, []v1beta1.ReferenceGrantTo{
+		{Kind: "Service", Name: &api},
+		{Kind: "Service", Name: &nginx},
+	}, grant.Spec.To)
+
+	// the grant is revoked once the route is no longer replicated
+	assert.NoError(t, grants.Store.Delete(route))
+	assert.NoError(t, grants.Sync("default/nginx"))
+
+	_, err = client.GatewayV1beta1().ReferenceGrants("default").Get(context.Background(), "httproute-nginx", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+func Test_ReferenceGrants_Sync_Unmanaged(t *testing.T) {
+	route := &v1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+	}
+	existing := &v1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "httproute-nginx", Namespace: "default"},
+	}
+
+	client := fake.NewSimpleClientset(existing)
+	grants, _ := newTestReferenceGrants(t, client, route, "default")
+	assert.NoError(t, grants.Sync("default/nginx"))
+
+	_, err := client.GatewayV1beta1().ReferenceGrants("default").Get(context.Background(), "httproute-nginx", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,102 @@
+package grpcroute
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/gateway"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions/apis/v1alpha2"
+	grantinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions/apis/v1beta1"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1alpha2.GRPCRoute]
+	ReferenceGrants *gateway.ReferenceGrants
+}
+
+// NewReplicator creates a new GRPCRoute replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer gatewayinformers.GRPCRouteInformer, grantInformer grantinformers.ReferenceGrantInformer) common.Replicator {
+	config.Kind = "GRPCRoute"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1alpha2.GRPCRoute](ctx, config, &repl)
+	repl.ReferenceGrants = gateway.NewReferenceGrants(repl.GenericReplicator, v1alpha2.SchemeGroupVersion, grantInformer, backendRefs)
+
+	return &repl
+}
+
+// ResourceClient returns the GRPCRoute client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1alpha2.GRPCRoute] {
+	return r.GatewayClient.GatewayV1alpha2().GRPCRoutes(namespace)
+}
+
+// GroupVersionKind is the kind of GRPCRoutes
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1alpha2.SchemeGroupVersion.WithKind("GRPCRoute")
+}
+
+// Prepare builds the replica of the source GRPCRoute with its hostnames rewritten for the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1alpha2.GRPCRoute) *v1alpha2.GRPCRoute {
+	shortened := common.NewHostMapping()
+	prepared := &v1alpha2.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Spec: v1alpha2.GRPCRouteSpec{
+			CommonRouteSpec: v1alpha2.CommonRouteSpec{
+				ParentRefs: gateway.PrepareParentRefs(source.Namespace, source.Spec.ParentRefs),
+			},
+			Hostnames: gateway.PrepareHostnames(r.GenericReplicator, namespace, source, source.Spec.Hostnames, shortened),
+			Rules:     prepareRules(source),
+		},
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// prepareRules points the backend references of the rules to the namespace of the source
+func prepareRules(source *v1alpha2.GRPCRoute) []v1alpha2.GRPCRouteRule {
+	return gateway.PrepareRules(source.Namespace, source.Spec.Rules, ruleBackendRefs)
+}
+
+// backendRefs lists the backend references of the replicas of the source
+func backendRefs(sourceObj interface{}) []v1alpha2.BackendObjectReference {
+	return gateway.BackendRefs(prepareRules(sourceObj.(*v1alpha2.GRPCRoute)), ruleBackendRefs)
+}
+
+// ruleBackendRefs lists the backend references of a rule, including those of request mirror filters
+func ruleBackendRefs(rule *v1alpha2.GRPCRouteRule) (refs []*v1alpha2.BackendObjectReference) {
+	refs = append(refs, mirrorBackendRefs(rule.Filters)...)
+	for i := range rule.BackendRefs {
+		refs = append(refs, &rule.BackendRefs[i].BackendObjectReference)
+		refs = append(refs, mirrorBackendRefs(rule.BackendRefs[i].Filters)...)
+	}
+
+	return
+}
+
+func mirrorBackendRefs(filters []v1alpha2.GRPCRouteFilter) (refs []*v1alpha2.BackendObjectReference) {
+	for i := range filters {
+		if filters[i].RequestMirror != nil {
+			refs = append(refs, &filters[i].RequestMirror.BackendRef)
+		}
+	}
+
+	return
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1alpha2.GRPCRoute, target *v1alpha2.GRPCRoute) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
@@ -0,0 +1,71 @@
+package grpcroute
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func backendRef(name string, namespace *v1alpha2.Namespace) v1alpha2.BackendRef {
+	return v1alpha2.BackendRef{BackendObjectReference: v1alpha2.BackendObjectReference{Name: v1alpha2.ObjectName(name), Namespace: namespace}}
+}
+
+func mirrorFilter(name string) v1alpha2.GRPCRouteFilter {
+	return v1alpha2.GRPCRouteFilter{
+		Type:          v1alpha2.GRPCRouteFilterRequestMirror,
+		RequestMirror: &v1alpha2.HTTPRequestMirrorFilter{BackendRef: v1alpha2.BackendObjectReference{Name: v1alpha2.ObjectName(name)}},
+	}
+}
+
+func Test_Prepare(t *testing.T) {
+	other := v1alpha2.Namespace("other")
+
+	source := &v1alpha2.GRPCRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "grpc",
+			Namespace:   "default",
+			Annotations: map[string]string{common.ReplicateTo: "feature-.*"},
+		},
+		Spec: v1alpha2.GRPCRouteSpec{
+			CommonRouteSpec: v1alpha2.CommonRouteSpec{
+				ParentRefs: []v1alpha2.ParentReference{{Name: "local"}},
+			},
+			Hostnames: []v1alpha2.Hostname{"grpc.example.com"},
+			Rules: []v1alpha2.GRPCRouteRule{{
+				Filters: []v1alpha2.GRPCRouteFilter{mirrorFilter("mirror")},
+				BackendRefs: []v1alpha2.GRPCBackendRef{
+					{BackendRef: backendRef("grpc", nil), Filters: []v1alpha2.GRPCRouteFilter{mirrorFilter("canary")}},
+					{BackendRef: backendRef("shared", &other)},
+				},
+			}},
+		},
+	}
+
+	r := &Replicator{TypedReplicator: &common.TypedReplicator[*v1alpha2.GRPCRoute]{GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "GRPCRoute"}}}}
+	prepared := r.Prepare("feature-a", source)
+
+	assert.Equal(t, "feature-a", prepared.Namespace)
+	assert.True(t, common.IsManagedBy(prepared))
+	assert.Equal(t, []v1alpha2.Hostname{"feature-a.example.com"}, prepared.Spec.Hostnames)
+	assert.Equal(t, v1alpha2.Namespace("default"), *prepared.Spec.ParentRefs[0].Namespace)
+
+	rule := prepared.Spec.Rules[0]
+	assert.Equal(t, v1alpha2.Namespace("default"), *rule.Filters[0].RequestMirror.BackendRef.Namespace)
+	assert.Equal(t, v1alpha2.Namespace("default"), *rule.BackendRefs[0].Namespace)
+	assert.Equal(t, v1alpha2.Namespace("default"), *rule.BackendRefs[0].Filters[0].RequestMirror.BackendRef.Namespace)
+	assert.Equal(t, v1alpha2.Namespace("other"), *rule.BackendRefs[1].Namespace)
+
+	// the source is left untouched
+	assert.Nil(t, source.Spec.Rules[0].BackendRefs[0].Namespace)
+	assert.Nil(t, source.Spec.Rules[0].BackendRefs[0].Filters[0].RequestMirror.BackendRef.Namespace)
+
+	var names []v1alpha2.ObjectName
+	for _, ref := range backendRefs(source) {
+		names = append(names, ref.Name)
+	}
+	assert.Equal(t, []v1alpha2.ObjectName{"mirror", "grpc", "canary", "shared"}, names)
+	assert.False(t, r.IsDrifted(prepared, prepared.DeepCopy()))
+}
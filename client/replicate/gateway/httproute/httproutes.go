@@ -0,0 +1,101 @@
+package httproute
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/gateway"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions/apis/v1beta1"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1beta1.HTTPRoute]
+	ReferenceGrants *gateway.ReferenceGrants
+}
+
+// NewReplicator creates a new HTTPRoute replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer gatewayinformers.HTTPRouteInformer, grantInformer gatewayinformers.ReferenceGrantInformer) common.Replicator {
+	config.Kind = "HTTPRoute"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1beta1.HTTPRoute](ctx, config, &repl)
+	repl.ReferenceGrants = gateway.NewReferenceGrants(repl.GenericReplicator, v1beta1.SchemeGroupVersion, grantInformer, backendRefs)
+
+	return &repl
+}
+
+// ResourceClient returns the HTTPRoute client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1beta1.HTTPRoute] {
+	return r.GatewayClient.GatewayV1beta1().HTTPRoutes(namespace)
+}
+
+// GroupVersionKind is the kind of HTTPRoutes
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1beta1.SchemeGroupVersion.WithKind("HTTPRoute")
+}
+
+// Prepare builds the replica of the source HTTPRoute with its hostnames rewritten for the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1beta1.HTTPRoute) *v1beta1.HTTPRoute {
+	shortened := common.NewHostMapping()
+	prepared := &v1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Spec: v1beta1.HTTPRouteSpec{
+			CommonRouteSpec: v1beta1.CommonRouteSpec{
+				ParentRefs: gateway.PrepareParentRefs(source.Namespace, source.Spec.ParentRefs),
+			},
+			Hostnames: gateway.PrepareHostnames(r.GenericReplicator, namespace, source, source.Spec.Hostnames, shortened),
+			Rules:     prepareRules(source),
+		},
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// prepareRules points the backend references of the rules to the namespace of the source
+func prepareRules(source *v1beta1.HTTPRoute) []v1beta1.HTTPRouteRule {
+	return gateway.PrepareRules(source.Namespace, source.Spec.Rules, ruleBackendRefs)
+}
+
+// backendRefs lists the backend references of the replicas of the source
+func backendRefs(sourceObj interface{}) []v1beta1.BackendObjectReference {
+	return gateway.BackendRefs(prepareRules(sourceObj.(*v1beta1.HTTPRoute)), ruleBackendRefs)
+}
+
+// ruleBackendRefs lists the backend references of a rule, including those of request mirror filters
+func ruleBackendRefs(rule *v1beta1.HTTPRouteRule) (refs []*v1beta1.BackendObjectReference) {
+	refs = append(refs, mirrorBackendRefs(rule.Filters)...)
+	for i := range rule.BackendRefs {
+		refs = append(refs, &rule.BackendRefs[i].BackendObjectReference)
+		refs = append(refs, mirrorBackendRefs(rule.BackendRefs[i].Filters)...)
+	}
+
+	return
+}
+
+func mirrorBackendRefs(filters []v1beta1.HTTPRouteFilter) (refs []*v1beta1.BackendObjectReference) {
+	for i := range filters {
+		if filters[i].RequestMirror != nil {
+			refs = append(refs, &filters[i].RequestMirror.BackendRef)
+		}
+	}
+
+	return
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1beta1.HTTPRoute, target *v1beta1.HTTPRoute) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
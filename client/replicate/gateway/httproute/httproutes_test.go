@@ -0,0 +1,63 @@
+package httproute
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func Test_Prepare(t *testing.T) {
+	shared := v1beta1.Namespace("gateway")
+	other := v1beta1.Namespace("other")
+
+	source := &v1beta1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx",
+			Namespace:   "default",
+			Annotations: map[string]string{common.ReplicateTo: "feature-.*"},
+		},
+		Spec: v1beta1.HTTPRouteSpec{
+			CommonRouteSpec: v1beta1.CommonRouteSpec{
+				ParentRefs: []v1beta1.ParentReference{{Name: "shared", Namespace: &shared}, {Name: "local"}},
+			},
+			Hostnames: []v1beta1.Hostname{"app.example.com"},
+			Rules: []v1beta1.HTTPRouteRule{{
+				Filters: []v1beta1.HTTPRouteFilter{{
+					Type:          v1beta1.HTTPRouteFilterRequestMirror,
+					RequestMirror: &v1beta1.HTTPRequestMirrorFilter{BackendRef: v1beta1.BackendObjectReference{Name: "mirror"}},
+				}},
+				BackendRefs: []v1beta1.HTTPBackendRef{
+					{BackendRef: v1beta1.BackendRef{BackendObjectReference: v1beta1.BackendObjectReference{Name: "nginx"}}},
+					{BackendRef: v1beta1.BackendRef{BackendObjectReference: v1beta1.BackendObjectReference{Name: "shared", Namespace: &other}}},
+				},
+			}},
+		},
+	}
+
+	r := &Replicator{TypedReplicator: &common.TypedReplicator[*v1beta1.HTTPRoute]{GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "HTTPRoute"}}}}
+	prepared := r.Prepare("feature-a", source)
+
+	assert.Equal(t, "feature-a", prepared.Namespace)
+	assert.True(t, common.IsManagedBy(prepared))
+	assert.Equal(t, []v1beta1.Hostname{"feature-a.example.com"}, prepared.Spec.Hostnames)
+	assert.Equal(t, v1beta1.Namespace("gateway"), *prepared.Spec.ParentRefs[0].Namespace)
+	assert.Equal(t, v1beta1.Namespace("default"), *prepared.Spec.ParentRefs[1].Namespace)
+
+	rule := prepared.Spec.Rules[0]
+	assert.Equal(t, v1beta1.Namespace("default"), *rule.Filters[0].RequestMirror.BackendRef.Namespace)
+	assert.Equal(t, v1beta1.Namespace("default"), *rule.BackendRefs[0].Namespace)
+	assert.Equal(t, v1beta1.Namespace("other"), *rule.BackendRefs[1].Namespace)
+
+	// the source is left untouched
+	assert.Nil(t, source.Spec.Rules[0].BackendRefs[0].Namespace)
+
+	var names []v1beta1.ObjectName
+	for _, ref := range backendRefs(source) {
+		names = append(names, ref.Name)
+	}
+	assert.Equal(t, []v1beta1.ObjectName{"mirror", "nginx", "shared"}, names)
+	assert.False(t, r.IsDrifted(prepared, prepared.DeepCopy()))
+}
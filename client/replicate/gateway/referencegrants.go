@@ -0,0 +1,226 @@
+package gateway
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions/apis/v1beta1"
+	gatewaylisters "sigs.k8s.io/gateway-api/pkg/client/listers/apis/v1beta1"
+)
+
+// ReferenceGrants manages the ReferenceGrants that allow the replicas of a route kind to reference the backends
+// in the namespace of their source. A single ReferenceGrant is managed in the source namespace for each source route.
+type ReferenceGrants struct {
+	*common.GenericReplicator
+
+	// GroupVersion is the API group and version of the route kind
+	GroupVersion schema.GroupVersion
+
+	// Lister serves ReferenceGrants from the shared informer cache
+	Lister gatewaylisters.ReferenceGrantLister
+
+	// BackendRefs lists the prepared backend references of a source route
+	BackendRefs func(source interface{}) []v1beta1.BackendObjectReference
+}
+
+// NewReferenceGrants manages the ReferenceGrants of the route kind replicated by the provided replicator. The
+// ReferenceGrant of a source route is synced once per reconcile of the route with the ReconcileSource update function.
+func NewReferenceGrants(repl *common.GenericReplicator, groupVersion schema.GroupVersion, informer gatewayinformers.ReferenceGrantInformer, backendRefs func(source interface{}) []v1beta1.BackendObjectReference) *ReferenceGrants {
+	grants := &ReferenceGrants{
+		GenericReplicator: repl,
+		GroupVersion:      groupVersion,
+		Lister:            informer.Lister(),
+		BackendRefs:       backendRefs,
+	}
+
+	repl.AddCacheSync(informer.Informer().HasSynced)
+	repl.UpdateFuncs.ReconcileSource = grants.Sync
+
+	return grants
+}
+
+// ReferenceGrantName is the name of the ReferenceGrant managed for the source route of the provided kind
+func ReferenceGrantName(kind, name string) string {
+	return fmt.Sprintf("%s-%s", strings.ToLower(kind), name)
+}
+
+// Sync creates, updates or deletes the ReferenceGrant of the source route stored under the provided key,
+// so that it allows references from exactly the namespaces selected by the replication rule of the route.
+// ReferenceGrants that are not managed by this controller are never modified.
+func (g *ReferenceGrants) Sync(sourceKey string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(sourceKey)
+	if err != nil {
+		return err
+	}
+
+	prepared, err := g.prepareReferenceGrant(sourceKey)
+	if err != nil {
+		return err
+	}
+
+	grantName := ReferenceGrantName(g.Kind, name)
+	grantKey := fmt.Sprintf("%s/%s", namespace, grantName)
+	logger := log.WithField("kind", g.Kind).WithField("source", sourceKey).WithField("grant", grantKey)
+
+	client := g.GatewayClient.GatewayV1beta1().ReferenceGrants(namespace)
+	existing, err := g.Lister.ReferenceGrants(namespace).Get(grantName)
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return errors.Wrapf(err, "Failed getting ReferenceGrant %s", grantKey)
+	}
+
+	if existing != nil && !common.IsManagedBy(existing) {
+		logger.Debugf("ReferenceGrant is not managed and will not be synced")
+		return nil
+	}
+
+	if prepared == nil {
+		if existing == nil {
+			return nil
+		}
+
+		logger.Infof("Deleting ReferenceGrant: %s", grantKey)
+		if err := client.Delete(g.Context, grantName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return errors.Wrapf(err, "Failed deleting ReferenceGrant %s", grantKey)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		logger.Infof("Creating ReferenceGrant: %s", grantKey)
+		if _, err := client.Create(g.Context, prepared, common.CreateOptions()); err != nil {
+			return errors.Wrapf(err, "Failed creating ReferenceGrant %s", grantKey)
+		}
+		return nil
+	}
+
+	if !common.MetadataDrifted(prepared, existing) && equality.Semantic.DeepEqual(prepared.Spec, existing.Spec) {
+		return nil
+	}
+
+	logger.Infof("Updating ReferenceGrant: %s", grantKey)
+	prepared.ResourceVersion = existing.ResourceVersion
+	if _, err := client.Update(g.Context, prepared, common.UpdateOptions()); err != nil {
+		return errors.Wrapf(err, "Failed updating ReferenceGrant %s", grantKey)
+	}
+	return nil
+}
+
+// prepareReferenceGrant builds the ReferenceGrant of the source route stored under the provided key,
+// nil is returned if the route is not replicated or does not reference any backends in its own namespace
+func (g *ReferenceGrants) prepareReferenceGrant(sourceKey string) (*v1beta1.ReferenceGrant, error) {
+	obj, exists, err := g.Store.GetByKey(sourceKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get %s %s from store", g.Kind, sourceKey)
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	source := common.MustGetObject(obj)
+	if common.IsManagedBy(source) || !common.HasReplicationAnnotations(source) {
+		return nil, nil
+	}
+
+	to := prepareReferenceGrantTo(source.GetNamespace(), g.BackendRefs(obj))
+	if len(to) == 0 {
+		return nil, nil
+	}
+
+	// invalid rules are reported when the route is reconciled
+	rule, _ := common.NewReplicationRule(source)
+	namespaces, err := g.ListRuleNamespaces(rule)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while listing namespaces")
+	}
+
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		names = append(names, namespace.Name)
+	}
+	sort.Strings(names)
+
+	from := make([]v1beta1.ReferenceGrantFrom, 0, len(names))
+	for _, name := range names {
+		from = append(from, v1beta1.ReferenceGrantFrom{
+			Group:     v1beta1.Group(g.GroupVersion.Group),
+			Kind:      v1beta1.Kind(g.Kind),
+			Namespace: v1beta1.Namespace(name),
+		})
+	}
+
+	return &v1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ReferenceGrantName(g.Kind, source.GetName()),
+			Namespace: source.GetNamespace(),
+			Labels:    map[string]string{common.ManagedByLabelKey: common.ManagedByLabelValue},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: g.GroupVersion.String(),
+				Kind:       g.Kind,
+				Name:       source.GetName(),
+				UID:        source.GetUID(),
+			}},
+		},
+		Spec: v1beta1.ReferenceGrantSpec{
+			From: from,
+			To:   to,
+		},
+	}, nil
+}
+
+// prepareReferenceGrantTo lists the distinct backends in the source namespace, sorted by group, kind and name
+func prepareReferenceGrantTo(sourceNamespace string, backendRefs []v1beta1.BackendObjectReference) (to []v1beta1.ReferenceGrantTo) {
+	seen := make(map[string]struct{})
+	for _, backendRef := range backendRefs {
+		if backendRef.Namespace == nil || string(*backendRef.Namespace) != sourceNamespace {
+			continue
+		}
+
+		// backends default to core Services
+		var group v1beta1.Group
+		if backendRef.Group != nil {
+			group = *backendRef.Group
+		}
+		kind := v1beta1.Kind("Service")
+		if backendRef.Kind != nil {
+			kind = *backendRef.Kind
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", group, kind, backendRef.Name)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		name := backendRef.Name
+		to = append(to, v1beta1.ReferenceGrantTo{Group: group, Kind: kind, Name: &name})
+	}
+
+	sort.Slice(to, func(i, j int) bool {
+		if to[i].Group != to[j].Group {
+			return to[i].Group < to[j].Group
+		}
+		if to[i].Kind != to[j].Kind {
+			return to[i].Kind < to[j].Kind
+		}
+		return *to[i].Name < *to[j].Name
+	})
+
+	return
+}
@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// Rule is a pointer to the rule of a route kind, which is deep copied when the rules of a route are prepared
+type Rule[R any] interface {
+	*R
+	DeepCopy() *R
+}
+
+// PrepareRules copies the rules of a source route and points the backend references listed by ruleBackendRefs to the
+// namespace of the source, so that the replicas keep routing to the backends of the source
+func PrepareRules[R any, P Rule[R]](sourceNamespace string, rules []R, ruleBackendRefs func(rule *R) []*v1beta1.BackendObjectReference) []R {
+	prepared := make([]R, 0, len(rules))
+	for i := range rules {
+		rule := *P(&rules[i]).DeepCopy()
+		for _, backendRef := range ruleBackendRefs(&rule) {
+			PrepareBackendRef(sourceNamespace, backendRef)
+		}
+		prepared = append(prepared, rule)
+	}
+
+	return prepared
+}
+
+// BackendRefs lists the backend references of the prepared rules of a route
+func BackendRefs[R any](rules []R, ruleBackendRefs func(rule *R) []*v1beta1.BackendObjectReference) (refs []v1beta1.BackendObjectReference) {
+	for i := range rules {
+		for _, backendRef := range ruleBackendRefs(&rules[i]) {
+			refs = append(refs, *backendRef)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,90 @@
+package tlsroute
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/gateway"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+	gatewayinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions/apis/v1alpha2"
+	grantinformers "sigs.k8s.io/gateway-api/pkg/client/informers/externalversions/apis/v1beta1"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1alpha2.TLSRoute]
+	ReferenceGrants *gateway.ReferenceGrants
+}
+
+// NewReplicator creates a new TLSRoute replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer gatewayinformers.TLSRouteInformer, grantInformer grantinformers.ReferenceGrantInformer) common.Replicator {
+	config.Kind = "TLSRoute"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1alpha2.TLSRoute](ctx, config, &repl)
+	repl.ReferenceGrants = gateway.NewReferenceGrants(repl.GenericReplicator, v1alpha2.SchemeGroupVersion, grantInformer, backendRefs)
+
+	return &repl
+}
+
+// ResourceClient returns the TLSRoute client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1alpha2.TLSRoute] {
+	return r.GatewayClient.GatewayV1alpha2().TLSRoutes(namespace)
+}
+
+// GroupVersionKind is the kind of TLSRoutes
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1alpha2.SchemeGroupVersion.WithKind("TLSRoute")
+}
+
+// Prepare builds the replica of the source TLSRoute with its hostnames rewritten for the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1alpha2.TLSRoute) *v1alpha2.TLSRoute {
+	shortened := common.NewHostMapping()
+	prepared := &v1alpha2.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Spec: v1alpha2.TLSRouteSpec{
+			CommonRouteSpec: v1alpha2.CommonRouteSpec{
+				ParentRefs: gateway.PrepareParentRefs(source.Namespace, source.Spec.ParentRefs),
+			},
+			Hostnames: gateway.PrepareHostnames(r.GenericReplicator, namespace, source, source.Spec.Hostnames, shortened),
+			Rules:     prepareRules(source),
+		},
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// prepareRules points the backend references of the rules to the namespace of the source
+func prepareRules(source *v1alpha2.TLSRoute) []v1alpha2.TLSRouteRule {
+	return gateway.PrepareRules(source.Namespace, source.Spec.Rules, ruleBackendRefs)
+}
+
+// backendRefs lists the backend references of the replicas of the source
+func backendRefs(sourceObj interface{}) []v1alpha2.BackendObjectReference {
+	return gateway.BackendRefs(prepareRules(sourceObj.(*v1alpha2.TLSRoute)), ruleBackendRefs)
+}
+
+// ruleBackendRefs lists the backend references of a rule
+func ruleBackendRefs(rule *v1alpha2.TLSRouteRule) (refs []*v1alpha2.BackendObjectReference) {
+	for i := range rule.BackendRefs {
+		refs = append(refs, &rule.BackendRefs[i].BackendObjectReference)
+	}
+
+	return
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1alpha2.TLSRoute, target *v1alpha2.TLSRoute) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
@@ -0,0 +1,57 @@
+package tlsroute
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func Test_Prepare(t *testing.T) {
+	shared := v1alpha2.Namespace("gateway")
+	other := v1alpha2.Namespace("other")
+
+	source := &v1alpha2.TLSRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "tls",
+			Namespace:   "default",
+			Annotations: map[string]string{common.ReplicateTo: "feature-.*"},
+		},
+		Spec: v1alpha2.TLSRouteSpec{
+			CommonRouteSpec: v1alpha2.CommonRouteSpec{
+				ParentRefs: []v1alpha2.ParentReference{{Name: "shared", Namespace: &shared}},
+			},
+			Hostnames: []v1alpha2.Hostname{"tls.example.com", "www.example.com"},
+			Rules: []v1alpha2.TLSRouteRule{{
+				BackendRefs: []v1alpha2.BackendRef{
+					{BackendObjectReference: v1alpha2.BackendObjectReference{Name: "tls"}},
+					{BackendObjectReference: v1alpha2.BackendObjectReference{Name: "shared", Namespace: &other}},
+				},
+			}},
+		},
+	}
+
+	r := &Replicator{TypedReplicator: &common.TypedReplicator[*v1alpha2.TLSRoute]{GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "TLSRoute"}}}}
+	prepared := r.Prepare("feature-a", source)
+
+	assert.Equal(t, "feature-a", prepared.Namespace)
+	assert.True(t, common.IsManagedBy(prepared))
+	// hostnames that are rewritten to the same host are deduplicated
+	assert.Equal(t, []v1alpha2.Hostname{"feature-a.example.com"}, prepared.Spec.Hostnames)
+	assert.Equal(t, v1alpha2.Namespace("gateway"), *prepared.Spec.ParentRefs[0].Namespace)
+
+	rule := prepared.Spec.Rules[0]
+	assert.Equal(t, v1alpha2.Namespace("default"), *rule.BackendRefs[0].Namespace)
+	assert.Equal(t, v1alpha2.Namespace("other"), *rule.BackendRefs[1].Namespace)
+
+	// the source is left untouched
+	assert.Nil(t, source.Spec.Rules[0].BackendRefs[0].Namespace)
+
+	assert.Equal(t, []v1alpha2.BackendObjectReference{
+		{Name: "tls", Namespace: rule.BackendRefs[0].Namespace},
+		{Name: "shared", Namespace: &other},
+	}, backendRefs(source))
+	assert.False(t, r.IsDrifted(prepared, prepared.DeepCopy()))
+}
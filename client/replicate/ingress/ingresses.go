@@ -2,122 +2,69 @@ package ingress
 
 import (
 	"context"
-	"fmt"
-	"time"
 
+	"github.com/alehechka/kube-external-sync/client/replicate/certmanager"
 	"github.com/alehechka/kube-external-sync/client/replicate/common"
-	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
-	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	networkinginformers "k8s.io/client-go/informers/networking/v1"
 )
 
 type Replicator struct {
-	*common.GenericReplicator
+	*common.TypedReplicator[*networkingv1.Ingress]
+	Certificates *certmanager.Certificates
 }
 
 // NewReplicator creates a new ingress replicator
-func NewReplicator(ctx context.Context, client kubernetes.Interface, resyncPeriod time.Duration, defaultIngressHostname string) common.Replicator {
-	repl := Replicator{
-		GenericReplicator: common.NewGenericReplicator(ctx, common.ReplicatorConfig{
-			Kind:                   "Ingress",
-			ObjType:                &networkingv1.Ingress{},
-			ResyncPeriod:           resyncPeriod,
-			DefaultIngressHostname: defaultIngressHostname,
-			Client:                 client,
-			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
-				return client.NetworkingV1().Ingresses(v1.NamespaceAll).List(ctx, lo)
-			},
-			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-				return client.NetworkingV1().Ingresses(v1.NamespaceAll).Watch(ctx, lo)
-			},
-		}),
-	}
-	repl.UpdateFuncs = common.UpdateFuncs{
-		ReplicateDataFrom:        repl.ReplicateDataFrom,
-		ReplicateObjectTo:        repl.ReplicateObjectTo,
-		DeleteReplicatedResource: repl.DeleteReplicatedResource,
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer networkinginformers.IngressInformer) common.Replicator {
+	config.Kind = "Ingress"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*networkingv1.Ingress](ctx, config, &repl)
+	if len(config.CertificateIssuer.Name) > 0 {
+		repl.Certificates = certmanager.NewCertificates(repl.GenericReplicator, repl.dnsNames)
 	}
 
 	return &repl
 }
 
-// ReplicateDataFrom takes a source object and copies over data to target object
-func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interface{}) error {
-	source := sourceObj.(*networkingv1.Ingress)
-	target := targetObj.(*networkingv1.Ingress)
-
-	logger := log.
-		WithField("kind", r.Kind).
-		WithField("source", common.MustGetKey(source)).
-		WithField("target", common.MustGetKey(target))
-
-	if !common.IsManagedBy(target) {
-		logger.Debugf("target is not managed and will not be synced")
-		return nil
-	}
+// ResourceClient returns the Ingress client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*networkingv1.Ingress] {
+	return r.Client.NetworkingV1().Ingresses(namespace)
+}
 
-	targetVersion, ok := target.Annotations[common.ReplicatedFromVersionAnnotation]
-	sourceVersion := source.ResourceVersion
+// GroupVersionKind is the kind of Ingresses
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return networkingv1.SchemeGroupVersion.WithKind("Ingress")
+}
 
-	if ok && targetVersion == sourceVersion {
-		logger.Debugf("target is already up-to-date")
+// References lists the names of the Secrets referenced by the TLS configuration of the replicas of the source
+func (r *Replicator) References(kind string, sourceObj interface{}) (names []string) {
+	if kind != "Secret" {
 		return nil
 	}
 
-	prepared := r.prepareIngress(target.Namespace, source)
-	service, err := r.Client.NetworkingV1().Ingresses(target.Namespace).Update(r.Context, prepared, metav1.UpdateOptions{})
-	if err != nil {
-		err = errors.Wrapf(err, "Failed updating target %s", common.MustGetKey(prepared))
-	} else if err = r.Store.Update(service); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s: %v", common.MustGetKey(prepared), err)
-	}
-	return err
-}
-
-// ReplicateObjectTo copies the whole object to target namespace
-func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, targetNamespace *v1.Namespace) error {
 	source := sourceObj.(*networkingv1.Ingress)
-	sourceKey := common.MustGetKey(source)
-	targetLocation := fmt.Sprintf("%s/%s", targetNamespace.Name, source.Name)
-
-	logger := log.WithField("source", sourceKey).WithField("target", targetLocation).WithField("kind", r.Kind)
-	logger.Infof("Replicating %s to %s", sourceKey, targetNamespace.Name)
-
-	targetResource, err := r.Client.NetworkingV1().Ingresses(targetNamespace.Name).Get(r.Context, source.Name, metav1.GetOptions{})
-	if err == nil && targetResource != nil {
-		return r.ReplicateDataFrom(source, targetResource)
-	}
-
-	prepared := r.prepareIngress(targetNamespace.Name, source)
-	service, err := r.Client.NetworkingV1().Ingresses(targetNamespace.Name).Create(r.Context, prepared, metav1.CreateOptions{})
-	if err != nil {
-		err = errors.Wrapf(err, "Failed creating target %s", common.MustGetKey(prepared))
-	} else if err = r.Store.Update(service); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s: %v", common.MustGetKey(prepared), err)
+	if certmanager.Issues(r.ReplicatorConfig, source) {
+		return nil
 	}
-	return err
-}
-
-// DeleteReplicatedResource deletes a resource replicated by ReplicateTo annotation
-func (r *Replicator) DeleteReplicatedResource(targetResource interface{}) error {
-	ingress := targetResource.(*networkingv1.Ingress)
 
-	if !common.IsManagedBy(ingress) {
-		log.WithField("kind", r.Kind).WithField("target", common.MustGetKey(ingress)).
-			Debugf("target is not managed and will not be deleted")
-		return nil
+	for _, tls := range r.prepareTLS(source.Namespace, source, nil) {
+		if len(tls.SecretName) > 0 {
+			names = append(names, tls.SecretName)
+		}
 	}
 
-	return r.Client.NetworkingV1().Ingresses(ingress.Namespace).Delete(r.Context, ingress.Name, metav1.DeleteOptions{})
+	return
 }
 
-func (r *Replicator) prepareIngress(namespace string, source *networkingv1.Ingress) *networkingv1.Ingress {
-	return &networkingv1.Ingress{
+// Prepare builds the replica of the source Ingress with its hosts rewritten for the provided namespace
+func (r *Replicator) Prepare(namespace string, source *networkingv1.Ingress) *networkingv1.Ingress {
+	shortened := common.NewHostMapping()
+	prepared := &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            source.Name,
 			Namespace:       namespace,
@@ -128,33 +75,57 @@ func (r *Replicator) prepareIngress(namespace string, source *networkingv1.Ingre
 		Spec: networkingv1.IngressSpec{
 			IngressClassName: source.Spec.IngressClassName,
 			DefaultBackend:   source.Spec.DefaultBackend,
-			TLS:              r.prepareTLS(namespace, source),
-			Rules:            r.prepareRules(namespace, source),
+			TLS:              r.prepareTLS(namespace, source, shortened),
+			Rules:            r.prepareRules(namespace, source, shortened),
 		},
 	}
+
+	common.SetHostMapping(prepared, shortened)
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// dnsNames lists the TLS hosts of the replica of the source that a Certificate is issued for
+func (r *Replicator) dnsNames(namespace string, sourceObj interface{}) (names []string) {
+	source := sourceObj.(*networkingv1.Ingress)
+	if !certmanager.Issues(r.ReplicatorConfig, source) {
+		return nil
+	}
+
+	for _, tls := range r.prepareTLS(namespace, source, nil) {
+		names = append(names, tls.Hosts...)
+	}
+
+	return
 }
 
-func (r *Replicator) prepareTLS(namespace string, source *networkingv1.Ingress) (ingressTLS []networkingv1.IngressTLS) {
+func (r *Replicator) prepareTLS(namespace string, source *networkingv1.Ingress, shortened *common.HostMapping) (ingressTLS []networkingv1.IngressTLS) {
 	annotations := source.GetAnnotations()
 
+	secretName := annotations[common.TLDSecretName]
+	if certmanager.Issues(r.ReplicatorConfig, source) {
+		secretName = certmanager.CertificateName(r.Kind, source.Name)
+	}
+
 	if tld, ok := annotations[common.TopLevelDomain]; ok {
 		return []networkingv1.IngressTLS{{
-			SecretName: annotations[common.TLDSecretName],
-			Hosts:      []string{common.PrepareTLD(namespace, tld)},
+			SecretName: secretName,
+			Hosts:      []string{r.PrepareMappedTLD(namespace, source, tld, shortened)},
 		}}
 	}
 
 	if r.HasDefaultIngressHostname() {
 		return []networkingv1.IngressTLS{{
-			SecretName: annotations[common.TLDSecretName],
-			Hosts:      []string{common.PrepareTLD(namespace, r.DefaultIngressHostname)},
+			SecretName: secretName,
+			Hosts:      []string{r.PrepareMappedTLD(namespace, source, r.DefaultIngressHostname, shortened)},
 		}}
 	}
 
 	for _, tls := range source.Spec.TLS {
 		entry := networkingv1.IngressTLS{SecretName: tls.SecretName}
 		for _, host := range tls.Hosts {
-			entry.Hosts = append(entry.Hosts, common.PrepareTLD(namespace, host))
+			entry.Hosts = append(entry.Hosts, r.PrepareMappedTLD(namespace, source, host, shortened))
 		}
 		ingressTLS = append(ingressTLS, entry)
 	}
@@ -162,19 +133,17 @@ func (r *Replicator) prepareTLS(namespace string, source *networkingv1.Ingress)
 	return
 }
 
-func (r *Replicator) prepareRules(namespace string, source *networkingv1.Ingress) (rules []networkingv1.IngressRule) {
+func (r *Replicator) prepareRules(namespace string, source *networkingv1.Ingress, shortened *common.HostMapping) (rules []networkingv1.IngressRule) {
 	tld, ok := source.GetAnnotations()[common.TopLevelDomain]
-	prepared := common.PrepareTLD(namespace, tld)
 
 	for _, rule := range source.Spec.Rules {
-		host := prepared
-		// If TLD annotation is not provided
-		if !ok {
-			if r.HasDefaultIngressHostname() {
-				host = common.PrepareTLD(namespace, r.DefaultIngressHostname)
-			} else {
-				host = common.PrepareTLD(namespace, rule.Host)
-			}
+		var host string
+		if ok {
+			host = r.PrepareMappedTLD(namespace, source, tld, shortened)
+		} else if r.HasDefaultIngressHostname() {
+			host = r.PrepareMappedTLD(namespace, source, r.DefaultIngressHostname, shortened)
+		} else {
+			host = r.PrepareMappedTLD(namespace, source, rule.Host, shortened)
 		}
 
 		rules = append(rules, networkingv1.IngressRule{
@@ -185,3 +154,8 @@ func (r *Replicator) prepareRules(namespace string, source *networkingv1.Ingress
 
 	return
 }
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *networkingv1.Ingress, target *networkingv1.Ingress) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
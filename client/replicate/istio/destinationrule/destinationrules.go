@@ -0,0 +1,55 @@
+package destinationrule
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/istio"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+}
+
+// NewReplicator creates a new Istio DestinationRule replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer informers.GenericInformer) common.Replicator {
+	config.Kind = "DestinationRule"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+
+	return &repl
+}
+
+// ResourceClient returns the dynamic DestinationRule client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(istio.DestinationRules).Namespace(namespace))
+}
+
+// GroupVersionKind is the kind of DestinationRules
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return istio.GroupVersion.WithKind("DestinationRule")
+}
+
+// Prepare builds the replica of the source DestinationRule in the provided namespace. A short host is pointed to the
+// Service in the source namespace, so that the traffic policies apply to the destinations of replicated VirtualServices.
+func (r *Replicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.GroupVersionKind(), source)
+
+	if host, ok, _ := unstructured.NestedString(prepared.Object, "spec", "host"); ok {
+		// the spec is known to be a map, as it contains the host
+		_ = unstructured.SetNestedField(prepared.Object, istio.PrepareServiceHost(source.GetNamespace(), r.ClusterDomain, host), "spec", "host")
+	}
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *Replicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
@@ -0,0 +1,57 @@
+package istio
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/dynamic"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersion is the Istio networking API version of the replicated resources
+var GroupVersion = schema.GroupVersion{Group: "networking.istio.io", Version: "v1beta1"}
+
+// Istio networking resources replicated with the dynamic client
+var (
+	VirtualServices  = GroupVersion.WithResource("virtualservices")
+	DestinationRules = GroupVersion.WithResource("destinationrules")
+)
+
+// MeshGateway is the reserved gateway name of the sidecars of the mesh
+const MeshGateway = "mesh"
+
+// MustParseHostPaths parses JSONPath expressions that are known to be valid
+func MustParseHostPaths(expressions ...string) []dynamic.HostPath {
+	paths := make([]dynamic.HostPath, 0, len(expressions))
+	for _, expression := range expressions {
+		path, err := dynamic.ParseHostPath(expression)
+		if err != nil {
+			panic(err)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths
+}
+
+// PrepareServiceHost points a short Service name, which Istio resolves in the namespace of the resource, to the fully
+// qualified name of the Service in the source namespace, so that the replicas keep routing to the source Services
+func PrepareServiceHost(sourceNamespace, clusterDomain, host string) string {
+	if len(host) == 0 || strings.Contains(host, ".") || strings.Contains(host, "*") {
+		return host
+	}
+
+	suffix, _ := common.MeshExternalNameSuffix(common.ServiceMeshIstio, clusterDomain)
+	return fmt.Sprintf("%s.%s.%s", host, sourceNamespace, suffix)
+}
+
+// PrepareGateway points a gateway name without a namespace, which Istio resolves in the namespace of the
+// VirtualService, to the source namespace, so that the replicas bind to the same shared Gateway as the source
+func PrepareGateway(sourceNamespace, gateway string) string {
+	if len(gateway) == 0 || gateway == MeshGateway || strings.Contains(gateway, "/") {
+		return gateway
+	}
+
+	return fmt.Sprintf("%s/%s", sourceNamespace, gateway)
+}
@@ -0,0 +1,119 @@
+package virtualservice
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/istio"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+var (
+	// hostPaths select the hostnames that are exposed by the gateways the VirtualService is bound to
+	hostPaths = istio.MustParseHostPaths(
+		"{.spec.hosts[*]}",
+		"{.spec.tls[*].match[*].sniHosts[*]}",
+	)
+
+	// gatewayPaths select the gateways the VirtualService, or each of its routes, is bound to
+	gatewayPaths = istio.MustParseHostPaths(
+		"{.spec.gateways[*]}",
+		"{.spec.http[*].match[*].gateways[*]}",
+		"{.spec.tls[*].match[*].gateways[*]}",
+		"{.spec.tcp[*].match[*].gateways[*]}",
+	)
+
+	// destinationPaths select the Services that traffic is routed or mirrored to
+	destinationPaths = istio.MustParseHostPaths(
+		"{.spec.http[*].route[*].destination.host}",
+		"{.spec.http[*].mirror.host}",
+		"{.spec.http[*].mirrors[*].destination.host}",
+		"{.spec.tls[*].route[*].destination.host}",
+		"{.spec.tcp[*].route[*].destination.host}",
+	)
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+}
+
+// NewReplicator creates a new Istio VirtualService replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer informers.GenericInformer) common.Replicator {
+	config.Kind = "VirtualService"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+
+	return &repl
+}
+
+// ResourceClient returns the dynamic VirtualService client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(istio.VirtualServices).Namespace(namespace))
+}
+
+// GroupVersionKind is the kind of VirtualServices
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return istio.GroupVersion.WithKind("VirtualService")
+}
+
+// Prepare builds the replica of the source VirtualService in the provided namespace. The hostnames of VirtualServices
+// bound to a gateway are rewritten for the namespace, while gateways and destinations keep pointing to the source namespace.
+func (r *Replicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.GroupVersionKind(), source)
+	shortened := common.NewHostMapping()
+
+	if isGatewayBound(source) {
+		for _, path := range hostPaths {
+			path.Rewrite(prepared.Object, func(host string) string {
+				if isServiceHost(host) {
+					return host
+				}
+				return r.PrepareHost(namespace, source, host, shortened)
+			})
+		}
+	}
+
+	for _, path := range gatewayPaths {
+		path.Rewrite(prepared.Object, func(gateway string) string {
+			return istio.PrepareGateway(source.GetNamespace(), gateway)
+		})
+	}
+
+	for _, path := range destinationPaths {
+		path.Rewrite(prepared.Object, func(host string) string {
+			return istio.PrepareServiceHost(source.GetNamespace(), r.ClusterDomain, host)
+		})
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// isGatewayBound checks whether the VirtualService is bound to a gateway other than the sidecars of the mesh,
+// which is the default when no gateways are listed
+func isGatewayBound(source *unstructured.Unstructured) bool {
+	gateways, _, _ := unstructured.NestedStringSlice(source.Object, "spec", "gateways")
+	for _, gateway := range gateways {
+		if gateway != istio.MeshGateway {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isServiceHost checks whether the host is the name of a Service within the cluster rather than an external hostname
+func isServiceHost(host string) bool {
+	return strings.HasSuffix(host, ".svc") || strings.Contains(host, ".svc.")
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *Replicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
@@ -0,0 +1,116 @@
+package virtualservice
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestReplicator() *Replicator {
+	return &Replicator{TypedReplicator: &common.TypedReplicator[*unstructured.Unstructured]{
+		GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "VirtualService"}},
+	}}
+}
+
+func Test_Prepare(t *testing.T) {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata": map[string]interface{}{
+			"name":        "reviews",
+			"namespace":   "default",
+			"annotations": map[string]interface{}{common.ReplicateTo: "feature-.*"},
+		},
+		"spec": map[string]interface{}{
+			"hosts":    []interface{}{"reviews.example.com", "reviews", "reviews.default.svc.cluster.local"},
+			"gateways": []interface{}{"public", "istio-system/internal", "mesh"},
+			"http": []interface{}{
+				map[string]interface{}{
+					"match": []interface{}{map[string]interface{}{"gateways": []interface{}{"public"}}},
+					"route": []interface{}{
+						map[string]interface{}{"destination": map[string]interface{}{"host": "reviews", "subset": "v2"}},
+						map[string]interface{}{"destination": map[string]interface{}{"host": "ratings.other.svc.cluster.local"}},
+					},
+					"mirror": map[string]interface{}{"host": "shadow"},
+				},
+			},
+			"tls": []interface{}{
+				map[string]interface{}{
+					"match": []interface{}{map[string]interface{}{"sniHosts": []interface{}{"reviews.example.com"}}},
+					"route": []interface{}{map[string]interface{}{"destination": map[string]interface{}{"host": "reviews"}}},
+				},
+			},
+		},
+	}}
+
+	r := newTestReplicator()
+	prepared := r.Prepare("feature-a", source)
+
+	assert.Equal(t, "feature-a", prepared.GetNamespace())
+	assert.True(t, common.IsManagedBy(prepared))
+	assert.Equal(t, "networking.istio.io/v1beta1", prepared.GetAPIVersion())
+
+	hosts, _, _ := unstructured.NestedStringSlice(prepared.Object, "spec", "hosts")
+	assert.Equal(t, []string{"feature-a.example.com", "reviews", "reviews.default.svc.cluster.local"}, hosts)
+
+	gateways, _, _ := unstructured.NestedStringSlice(prepared.Object, "spec", "gateways")
+	assert.Equal(t, []string{"default/public", "istio-system/internal", "mesh"}, gateways)
+
+	http := prepared.Object["spec"].(map[string]interface{})["http"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{"default/public"}, http["match"].([]interface{})[0].(map[string]interface{})["gateways"])
+	assert.Equal(t, map[string]interface{}{"host": "reviews.default.svc.cluster.local", "subset": "v2"},
+		http["route"].([]interface{})[0].(map[string]interface{})["destination"])
+	assert.Equal(t, map[string]interface{}{"host": "ratings.other.svc.cluster.local"},
+		http["route"].([]interface{})[1].(map[string]interface{})["destination"])
+	assert.Equal(t, map[string]interface{}{"host": "shadow.default.svc.cluster.local"}, http["mirror"])
+
+	tls := prepared.Object["spec"].(map[string]interface{})["tls"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{"feature-a.example.com"}, tls["match"].([]interface{})[0].(map[string]interface{})["sniHosts"])
+
+	// the source is left untouched
+	assert.Equal(t, []interface{}{"public", "istio-system/internal", "mesh"}, source.Object["spec"].(map[string]interface{})["gateways"])
+
+	assert.False(t, r.IsDrifted(prepared, prepared.DeepCopy()))
+}
+
+func Test_Prepare_Mesh(t *testing.T) {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "reviews", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"hosts": []interface{}{"reviews.example.com"},
+		},
+	}}
+
+	prepared := newTestReplicator().Prepare("feature-a", source)
+
+	// VirtualServices that are only bound to the sidecars of the mesh keep their hosts
+	hosts, _, _ := unstructured.NestedStringSlice(prepared.Object, "spec", "hosts")
+	assert.Equal(t, []string{"reviews.example.com"}, hosts)
+}
+
+func Test_Prepare_ShortenedHosts(t *testing.T) {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata": map[string]interface{}{
+			"name":        "reviews",
+			"namespace":   "default",
+			"annotations": map[string]interface{}{common.ReplicateTo: "feature-.*", common.ShortenedHostsAnnotation: "stale"},
+		},
+		"spec": map[string]interface{}{
+			"hosts":    []interface{}{"reviews.example.com"},
+			"gateways": []interface{}{"public"},
+		},
+	}}
+	namespace := "feature-a-very-long-branch-name-that-exceeds-the-dns-label-limit"
+
+	prepared := newTestReplicator().Prepare(namespace, source)
+	hosts, _, _ := unstructured.NestedStringSlice(prepared.Object, "spec", "hosts")
+	assert.Equal(t, []string{"feature-a-very-long-branch-name-that-exceeds-the-dns-l-05e5f97c.example.com"}, hosts)
+	assert.Equal(t, namespace+".example.com="+hosts[0], prepared.GetAnnotations()[common.ShortenedHostsAnnotation])
+
+	prepared = newTestReplicator().Prepare("feature-a", source)
+	assert.NotContains(t, prepared.GetAnnotations(), common.ShortenedHostsAnnotation)
+}
@@ -0,0 +1,64 @@
+package secret
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1.Secret]
+}
+
+// NewReplicator creates a new secret replicator. Secrets are replicated alongside the replicas of the provided
+// replicators that reference them, as well as by their own ReplicateTo or ReplicateToMatching annotations.
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer coreinformers.SecretInformer, referencing ...common.Replicator) common.Replicator {
+	config.Kind = "Secret"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1.Secret](ctx, config, &repl)
+	repl.ReplicateReferencedBy(referencing...)
+
+	return &repl
+}
+
+// ResourceClient returns the Secret client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1.Secret] {
+	return r.Client.CoreV1().Secrets(namespace)
+}
+
+// GroupVersionKind is the kind of Secrets
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1.SchemeGroupVersion.WithKind("Secret")
+}
+
+// Prepare builds the replica of the source Secret in the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1.Secret) *v1.Secret {
+	prepared := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1.Secret, target *v1.Secret) bool {
+	return common.MetadataDrifted(prepared, target) ||
+		prepared.Type != target.Type ||
+		!equality.Semantic.DeepEqual(prepared.Data, target.Data)
+}
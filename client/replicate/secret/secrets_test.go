@@ -0,0 +1,75 @@
+package secret
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/ingress"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Replicator_ReplicatesReferencedSecrets(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-0"}},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls-cert", Namespace: "default"},
+			Type:       v1.SecretTypeTLS,
+			Data:       map[string][]byte{v1.TLSCertKey: []byte("cert"), v1.TLSPrivateKeyKey: []byte("key")},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nginx",
+				Namespace: "default",
+				Annotations: map[string]string{
+					common.ReplicateTo:    "feature-.*",
+					common.TopLevelDomain: "*.example.com",
+					common.TLDSecretName:  "tls-cert",
+				},
+			},
+		},
+	)
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	config := common.ReplicatorConfig{
+		Client:           client,
+		NamespaceWatcher: common.NewNamespaceWatcher(factory.Core().V1().Namespaces()),
+	}
+
+	ingresses := ingress.NewReplicator(ctx, config, factory.Networking().V1().Ingresses())
+	secrets := NewReplicator(ctx, config, factory.Core().V1().Secrets(), ingresses)
+
+	factory.Start(ctx.Done())
+	go ingresses.Run(ctx)
+	go secrets.Run(ctx)
+
+	assert.Eventually(t, func() bool {
+		replica, err := client.CoreV1().Secrets("feature-0").Get(ctx, "tls-cert", metav1.GetOptions{})
+		return err == nil && common.IsManagedBy(replica) && string(replica.Data[v1.TLSCertKey]) == "cert"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the secret is deleted with the last ingress referencing it
+	source, err := client.NetworkingV1().Ingresses("default").Get(ctx, "nginx", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	delete(source.Annotations, common.TLDSecretName)
+	_, err = client.NetworkingV1().Ingresses("default").Update(ctx, source, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, err := client.CoreV1().Secrets("feature-0").Get(ctx, "tls-cert", metav1.GetOptions{})
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err = client.NetworkingV1().Ingresses("feature-0").Get(ctx, "nginx", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
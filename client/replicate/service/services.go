@@ -3,119 +3,44 @@ package service
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/alehechka/kube-external-sync/client/replicate/common"
-	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	coreinformers "k8s.io/client-go/informers/core/v1"
 )
 
 type Replicator struct {
-	*common.GenericReplicator
+	*common.TypedReplicator[*v1.Service]
 }
 
 // NewReplicator creates a new service replicator
-func NewReplicator(ctx context.Context, client kubernetes.Interface, resyncPeriod time.Duration) common.Replicator {
-	repl := Replicator{
-		GenericReplicator: common.NewGenericReplicator(ctx, common.ReplicatorConfig{
-			Kind:         "Service",
-			ObjType:      &v1.Service{},
-			ResyncPeriod: resyncPeriod,
-			Client:       client,
-			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
-				return client.CoreV1().Services(v1.NamespaceAll).List(ctx, lo)
-			},
-			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-				return client.CoreV1().Services(v1.NamespaceAll).Watch(ctx, lo)
-			},
-		}),
-	}
-	repl.UpdateFuncs = common.UpdateFuncs{
-		ReplicateDataFrom:        repl.ReplicateDataFrom,
-		ReplicateObjectTo:        repl.ReplicateObjectTo,
-		DeleteReplicatedResource: repl.DeleteReplicatedResource,
-	}
-
-	return &repl
-}
-
-// ReplicateDataFrom takes a source object and copies over data to target object
-func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interface{}) error {
-	source := sourceObj.(*v1.Service)
-	target := targetObj.(*v1.Service)
-
-	logger := log.
-		WithField("kind", r.Kind).
-		WithField("source", common.MustGetKey(source)).
-		WithField("target", common.MustGetKey(target))
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer coreinformers.ServiceInformer) common.Replicator {
+	config.Kind = "Service"
+	config.Informer = informer.Informer()
 
-	if !common.IsManagedBy(target) {
-		logger.Debugf("target is not managed and will not be synced")
-		return nil
-	}
-
-	targetVersion, ok := target.Annotations[common.ReplicatedFromVersionAnnotation]
-	sourceVersion := source.ResourceVersion
-
-	if ok && targetVersion == sourceVersion {
-		logger.Debugf("target is already up-to-date")
-		return nil
-	}
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1.Service](ctx, config, &repl)
 
-	prepared := prepareExternalNameService(target.Namespace, source)
-	service, err := r.Client.CoreV1().Services(target.Namespace).Update(r.Context, prepared, metav1.UpdateOptions{})
-	if err != nil {
-		err = errors.Wrapf(err, "Failed updating target %s", common.MustGetKey(prepared))
-	} else if err = r.Store.Update(service); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s: %v", common.MustGetKey(prepared), err)
-	}
-	return err
+	return &repl
 }
 
-// ReplicateObjectTo copies the whole object to target namespace
-func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, targetNamespace *v1.Namespace) error {
-	source := sourceObj.(*v1.Service)
-	sourceKey := common.MustGetKey(source)
-	targetLocation := fmt.Sprintf("%s/%s", targetNamespace.Name, source.Name)
-
-	logger := log.WithField("source", sourceKey).WithField("target", targetLocation).WithField("kind", r.Kind)
-	logger.Infof("Replicating %s to %s", sourceKey, targetNamespace.Name)
-
-	targetResource, err := r.Client.CoreV1().Services(targetNamespace.Name).Get(r.Context, source.Name, metav1.GetOptions{})
-	if err == nil && targetResource != nil {
-		return r.ReplicateDataFrom(source, targetResource)
-	}
-
-	prepared := prepareExternalNameService(targetNamespace.Name, source)
-	service, err := r.Client.CoreV1().Services(targetNamespace.Name).Create(r.Context, prepared, metav1.CreateOptions{})
-	if err != nil {
-		err = errors.Wrapf(err, "Failed creating target %s", common.MustGetKey(prepared))
-	} else if err = r.Store.Update(service); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s: %v", common.MustGetKey(prepared), err)
-	}
-	return err
+// ResourceClient returns the Service client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1.Service] {
+	return r.Client.CoreV1().Services(namespace)
 }
 
-// DeleteReplicatedResource deletes a resource replicated by ReplicateTo annotation
-func (r *Replicator) DeleteReplicatedResource(targetResource interface{}) error {
-	service := targetResource.(*v1.Service)
-
-	if !common.IsManagedBy(service) {
-		log.WithField("kind", r.Kind).WithField("target", common.MustGetKey(service)).
-			Debugf("target is not managed and will not be deleted")
-		return nil
-	}
-
-	return r.Client.CoreV1().Services(service.Namespace).Delete(r.Context, service.Name, metav1.DeleteOptions{})
+// GroupVersionKind is the kind of Services
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1.SchemeGroupVersion.WithKind("Service")
 }
 
-func prepareExternalNameService(namespace string, source *v1.Service) *v1.Service {
-	return &v1.Service{
+// Prepare builds an ExternalName Service in the provided namespace pointing to the source Service
+func (r *Replicator) Prepare(namespace string, source *v1.Service) *v1.Service {
+	prepared := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            source.Name,
 			Namespace:       namespace,
@@ -125,20 +50,44 @@ func prepareExternalNameService(namespace string, source *v1.Service) *v1.Servic
 		},
 		Spec: v1.ServiceSpec{
 			Type:         v1.ServiceTypeExternalName,
-			ExternalName: prepareExternalName(source.Namespace, source),
+			ExternalName: r.prepareExternalName(source.Namespace, source),
 			Ports:        source.Spec.Ports,
 		},
 	}
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
 }
 
-func prepareExternalName(namespace string, source *v1.Service) string {
-	return fmt.Sprintf("%s.%s.%s", source.Name, namespace, getExternalNameSuffix(source))
+func (r *Replicator) prepareExternalName(namespace string, source *v1.Service) string {
+	return fmt.Sprintf("%s.%s.%s", source.Name, namespace, r.getExternalNameSuffix(source))
 }
 
-func getExternalNameSuffix(source *v1.Service) string {
+// getExternalNameSuffix prefers the ExternalNameSuffix annotation over the suffix of the service mesh selected by the
+// ServiceMesh annotation or the controller
+func (r *Replicator) getExternalNameSuffix(source *v1.Service) string {
 	if suffix, ok := source.Annotations[common.ExternalNameSuffix]; ok && len(suffix) > 0 {
 		return suffix
 	}
 
-	return common.DefaultExternalNameSuffix
+	mesh := r.ServiceMesh
+	if annotated, ok := source.Annotations[common.ServiceMesh]; ok && len(annotated) > 0 {
+		mesh = annotated
+	}
+
+	suffix, ok := common.MeshExternalNameSuffix(mesh, r.ClusterDomain)
+	if !ok {
+		log.WithField("kind", r.Kind).WithField("source", common.MustGetKey(source)).
+			Warnf("unknown service mesh %s, using the ExternalName suffix %s", mesh, suffix)
+	}
+
+	return suffix
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1.Service, target *v1.Service) bool {
+	return common.MetadataDrifted(prepared, target) ||
+		prepared.Spec.Type != target.Spec.Type ||
+		prepared.Spec.ExternalName != target.Spec.ExternalName ||
+		!equality.Semantic.DeepEqual(prepared.Spec.Ports, target.Spec.Ports)
 }
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_getExternalNameSuffix(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceMesh   string
+		clusterDomain string
+		annotations   map[string]string
+		expected      string
+	}{
+		{
+			name:     "default",
+			expected: "svc.cluster.local",
+		},
+		{
+			name:          "cluster domain",
+			serviceMesh:   common.ServiceMeshIstio,
+			clusterDomain: "example.internal",
+			expected:      "svc.example.internal",
+		},
+		{
+			name:        "traefik mesh",
+			serviceMesh: common.ServiceMeshTraefik,
+			expected:    "traefik.mesh",
+		},
+		{
+			name:        "service mesh annotation",
+			serviceMesh: common.ServiceMeshTraefik,
+			annotations: map[string]string{common.ServiceMesh: common.ServiceMeshIstio},
+			expected:    "svc.cluster.local",
+		},
+		{
+			name:        "external name suffix annotation",
+			serviceMesh: common.ServiceMeshIstio,
+			annotations: map[string]string{common.ServiceMesh: common.ServiceMeshIstio, common.ExternalNameSuffix: "svc.mesh.local"},
+			expected:    "svc.mesh.local",
+		},
+		{
+			name:        "unknown service mesh",
+			annotations: map[string]string{common.ServiceMesh: "linkerd"},
+			expected:    common.DefaultExternalNameSuffix,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &Replicator{TypedReplicator: &common.TypedReplicator[*v1.Service]{
+				GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{
+					Kind:          "Service",
+					ServiceMesh:   test.serviceMesh,
+					ClusterDomain: test.clusterDomain,
+				}},
+			}}
+
+			source := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default", Annotations: test.annotations}}
+			assert.Equal(t, test.expected, r.getExternalNameSuffix(source))
+		})
+	}
+}
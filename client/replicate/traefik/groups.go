@@ -0,0 +1,58 @@
+package traefik
+
+import (
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// Traefik serves its CRDs in the traefik.containo.us API group up to v2.9. Traefik v2.10 and v2.11 serve both groups,
+// Traefik v3 only serves the traefik.io group.
+var (
+	ContainousGroupVersion = schema.GroupVersion{Group: "traefik.containo.us", Version: "v1alpha1"}
+	GroupVersion           = schema.GroupVersion{Group: "traefik.io", Version: "v1alpha1"}
+)
+
+// traefik.io resources replicated with the dynamic client
+var (
+	IngressRoutes    = GroupVersion.WithResource("ingressroutes")
+	IngressRouteTCPs = GroupVersion.WithResource("ingressroutetcps")
+	IngressRouteUDPs = GroupVersion.WithResource("ingressrouteudps")
+	Middlewares      = GroupVersion.WithResource("middlewares")
+	TraefikServices  = GroupVersion.WithResource("traefikservices")
+)
+
+// ServedGroupVersions discovers which of the Traefik API groups have the IngressRoute CRD installed
+func ServedGroupVersions(client discovery.DiscoveryInterface) (served []schema.GroupVersion, err error) {
+	for _, gv := range []schema.GroupVersion{ContainousGroupVersion, GroupVersion} {
+		resources, err := client.ServerResourcesForGroupVersion(gv.String())
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to discover the resources of %s", gv)
+		}
+
+		for _, resource := range resources.APIResources {
+			if resource.Name == IngressRoutes.Resource {
+				served = append(served, gv)
+				break
+			}
+		}
+	}
+
+	return served, nil
+}
+
+// DefaultRuleSyntax infers the syntax of traefik.io rules from the served groups. Traefik v2.10 and v2.11 still
+// serve traefik.containo.us and use the v2 syntax, only Traefik v3 serves traefik.io alone.
+func DefaultRuleSyntax(served []schema.GroupVersion) string {
+	for _, gv := range served {
+		if gv == ContainousGroupVersion {
+			return RuleSyntaxV2
+		}
+	}
+
+	return RuleSyntaxV3
+}
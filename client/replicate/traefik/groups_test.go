@@ -0,0 +1,44 @@
+package traefik
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_ServedGroupVersions(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Resources = []*metav1.APIResourceList{
+		{GroupVersion: GroupVersion.String(), APIResources: []metav1.APIResource{{Name: "ingressroutes"}, {Name: "middlewares"}}},
+	}
+
+	served, err := ServedGroupVersions(client.Discovery())
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.GroupVersion{GroupVersion}, served)
+
+	client.Resources = append(client.Resources, &metav1.APIResourceList{
+		GroupVersion: ContainousGroupVersion.String(), APIResources: []metav1.APIResource{{Name: "ingressroutes"}},
+	})
+	served, err = ServedGroupVersions(client.Discovery())
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.GroupVersion{ContainousGroupVersion, GroupVersion}, served)
+}
+
+func Test_ServedGroupVersions_None(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.Resources = []*metav1.APIResourceList{
+		{GroupVersion: ContainousGroupVersion.String(), APIResources: []metav1.APIResource{{Name: "middlewares"}}},
+	}
+
+	served, err := ServedGroupVersions(client.Discovery())
+	assert.NoError(t, err)
+	assert.Empty(t, served)
+}
+
+func Test_DefaultRuleSyntax(t *testing.T) {
+	assert.Equal(t, RuleSyntaxV3, DefaultRuleSyntax([]schema.GroupVersion{GroupVersion}))
+	assert.Equal(t, RuleSyntaxV2, DefaultRuleSyntax([]schema.GroupVersion{ContainousGroupVersion, GroupVersion}))
+}
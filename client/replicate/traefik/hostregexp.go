@@ -0,0 +1,156 @@
+package traefik
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RewriteHostRegexp rewrites the literal domain suffix of a HostRegexp or HostSNIRegexp pattern in the v2 syntax, such as
+// "{subdomain:[a-z]+}.example.com", and leaves its placeholders intact. The placeholders stand in for the leading
+// labels of the host, so the suffix is rewritten as the wildcard host "*.example.com". Patterns without placeholders
+// are rewritten as plain hosts.
+func RewriteHostRegexp(pattern string, rewrite func(host string) string) (string, error) {
+	end, err := placeholdersEnd(pattern)
+	if err != nil {
+		return pattern, err
+	}
+
+	if end == 0 {
+		if !isDomain(pattern) {
+			return pattern, errors.Errorf("pattern %q is not a hostname", pattern)
+		}
+		return rewrite(pattern), nil
+	}
+
+	prefix, suffix := pattern[:end], pattern[end:]
+	if len(suffix) == 0 {
+		return pattern, errors.Errorf("pattern %q has no literal domain suffix", pattern)
+	}
+	if !strings.HasPrefix(suffix, ".") {
+		return pattern, errors.Errorf("literal suffix %q of pattern %q does not start at a domain label", suffix, pattern)
+	}
+
+	domain := suffix[1:]
+	if !isDomain(domain) {
+		return pattern, errors.Errorf("literal suffix %q of pattern %q is not a domain", suffix, pattern)
+	}
+	if !strings.Contains(domain, ".") {
+		return pattern, errors.Errorf("literal suffix %q of pattern %q is a top-level domain", suffix, pattern)
+	}
+
+	return prefix + "." + rewrite("*."+domain), nil
+}
+
+// RewriteHostRegexpV3 rewrites the literal domain suffix of a HostRegexp or HostSNIRegexp regular expression in the v3
+// syntax, such as "^[a-z]+\\.example\\.com$", and leaves the rest of the expression intact. Like RewriteHostRegexp, the
+// suffix is rewritten as the wildcard host "*.example.com". Expressions that only match a literal host are rewritten
+// as hosts.
+func RewriteHostRegexpV3(pattern string, rewrite func(host string) string) (string, error) {
+	body, anchor := strings.TrimSuffix(pattern, "$"), ""
+	if len(body) < len(pattern) {
+		anchor = "$"
+	}
+
+	start := literalSuffixStart(body)
+	prefix, suffix := body[:start], body[start:]
+
+	if prefix == "" || prefix == "^" {
+		host := strings.ReplaceAll(suffix, `\.`, ".")
+		if !isDomain(host) {
+			return pattern, errors.Errorf("pattern %q is not a hostname", pattern)
+		}
+		return prefix + regexp.QuoteMeta(rewrite(host)) + anchor, nil
+	}
+
+	// the literal characters before the first dot belong to the leading label, which is matched by the expression
+	if index := strings.Index(suffix, `\.`); index > 0 {
+		prefix, suffix = body[:start+index], body[start+index:]
+	}
+
+	if len(suffix) == 0 {
+		return pattern, errors.Errorf("pattern %q has no literal domain suffix", pattern)
+	}
+	if !strings.HasPrefix(suffix, `\.`) {
+		if strings.HasSuffix(prefix, ".") {
+			return pattern, errors.Errorf("literal suffix %q of pattern %q follows an unescaped '.', which matches any character", suffix, pattern)
+		}
+		return pattern, errors.Errorf("literal suffix %q of pattern %q does not start at a domain label", suffix, pattern)
+	}
+
+	domain := strings.ReplaceAll(suffix[2:], `\.`, ".")
+	if !isDomain(domain) {
+		return pattern, errors.Errorf("literal suffix %q of pattern %q is not a domain", suffix, pattern)
+	}
+	if !strings.Contains(domain, ".") {
+		return pattern, errors.Errorf("literal suffix %q of pattern %q is a top-level domain", suffix, pattern)
+	}
+
+	return prefix + `\.` + regexp.QuoteMeta(rewrite("*."+domain)) + anchor, nil
+}
+
+// literalSuffixStart returns the offset of the longest suffix of the regular expression that only consists of letters,
+// digits, hyphens and escaped dots
+func literalSuffixStart(expression string) int {
+	start := len(expression)
+	for start > 0 {
+		// a backslash preceding the character, unless it is escaped itself, makes the character a class such as \d
+		escaped := start > 1 && expression[start-2] == '\\' && (start < 3 || expression[start-3] != '\\')
+
+		switch c := expression[start-1]; {
+		case c == '.' && escaped:
+			start -= 2
+		case (c == '-' || isIdent(c)) && c != '_' && !escaped:
+			start--
+		default:
+			return start
+		}
+	}
+
+	return start
+}
+
+// placeholdersEnd returns the offset following the last {name} or {name:regexp} placeholder of the pattern, or 0 if it
+// has none. Like Traefik, braces nested in the regexp of a placeholder are balanced.
+func placeholdersEnd(pattern string) (int, error) {
+	end, depth, start := 0, 0, 0
+	for index := 0; index < len(pattern); index++ {
+		switch pattern[index] {
+		case '{':
+			if depth == 0 {
+				start = index
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				return 0, errors.Errorf("unexpected '}' at offset %d of pattern %q", index, pattern)
+			}
+			if depth--; depth == 0 {
+				end = index + 1
+			}
+		}
+	}
+
+	if depth > 0 {
+		return 0, errors.Errorf("unterminated placeholder at offset %d of pattern %q", start, pattern)
+	}
+
+	return end, nil
+}
+
+// isDomain checks whether the value consists of dot-separated, non-empty labels of letters, digits and hyphens
+func isDomain(value string) bool {
+	for _, label := range strings.Split(value, ".") {
+		if len(label) == 0 {
+			return false
+		}
+		for index := 0; index < len(label); index++ {
+			if c := label[index]; c == '_' || (c != '-' && !isIdent(c)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,87 @@
+package traefik
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareDefault(host string) string {
+	return common.PrepareTLD("default", host)
+}
+
+func Test_RewriteHostRegexp(t *testing.T) {
+	cases := map[string]string{
+		"{subdomain:[a-z]+}.example.com":   "{subdomain:[a-z]+}.default.example.com",
+		"{subdomain}.api.example.com":      "{subdomain}.default.api.example.com",
+		"api-{env:(dev|prod)}.example.com": "api-{env:(dev|prod)}.default.example.com",
+		"{a}.{b:[0-9]{2,3}}.example.com":   "{a}.{b:[0-9]{2,3}}.default.example.com",
+		"subdomain.example.com":            "default.example.com",
+	}
+
+	for pattern, expected := range cases {
+		rewritten, err := RewriteHostRegexp(pattern, prepareDefault)
+		assert.NoError(t, err, pattern)
+		assert.Equal(t, expected, rewritten, pattern)
+	}
+}
+
+func Test_RewriteHostRegexp_Errors(t *testing.T) {
+	cases := map[string]string{
+		"{host:.+}":                "pattern \"{host:.+}\" has no literal domain suffix",
+		"{subdomain}example.com":   "literal suffix \"example.com\" of pattern \"{subdomain}example.com\" does not start at a domain label",
+		"{subdomain}.example..com": "literal suffix \".example..com\" of pattern \"{subdomain}.example..com\" is not a domain",
+		"{subdomain}.com":          "literal suffix \".com\" of pattern \"{subdomain}.com\" is a top-level domain",
+		"{subdomain.example.com":   "unterminated placeholder at offset 0 of pattern \"{subdomain.example.com\"",
+		"subdomain}.example.com":   "unexpected '}' at offset 9 of pattern \"subdomain}.example.com\"",
+		"example\\.com":            "pattern \"example\\\\.com\" is not a hostname",
+	}
+
+	for pattern, message := range cases {
+		rewritten, err := RewriteHostRegexp(pattern, prepareDefault)
+		assert.EqualError(t, err, message, pattern)
+		assert.Equal(t, pattern, rewritten, pattern)
+	}
+}
+
+func Test_RewriteHosts_Error(t *testing.T) {
+	rule, err := ParseRule("Host(`a.example.com`) || HostRegexp(`{host:.+}`)")
+	assert.NoError(t, err)
+
+	err = RewriteHosts(rule, RuleSyntaxV2, prepareDefault)
+	assert.EqualError(t, err, "can not rewrite HostRegexp at offset 36 of rule: pattern \"{host:.+}\" has no literal domain suffix")
+}
+
+func Test_RewriteHostRegexpV3(t *testing.T) {
+	cases := map[string]string{
+		`^[a-z]+\.example\.com$`:         `^[a-z]+\.default\.example\.com$`,
+		`.+\.api\.example\.com`:          `.+\.default\.api\.example\.com`,
+		`^(dev|prod)-app\.example\.com$`: `^(dev|prod)-app\.default\.example\.com$`,
+		`^\w+\.example\.com$`:            `^\w+\.default\.example\.com$`,
+		`^subdomain\.example\.com$`:      `^default\.example\.com$`,
+	}
+
+	for pattern, expected := range cases {
+		rewritten, err := RewriteHostRegexpV3(pattern, prepareDefault)
+		assert.NoError(t, err, pattern)
+		assert.Equal(t, expected, rewritten, pattern)
+	}
+}
+
+func Test_RewriteHostRegexpV3_Errors(t *testing.T) {
+	cases := map[string]string{
+		`^.+$`:                    `pattern "^.+$" has no literal domain suffix`,
+		`^.+.example.com$`:        `literal suffix "com" of pattern "^.+.example.com$" follows an unescaped '.', which matches any character`,
+		`^[a-z]+example\.com$`:    `literal suffix "\\.com" of pattern "^[a-z]+example\\.com$" is a top-level domain`,
+		`^[a-z]+example$`:         `literal suffix "example" of pattern "^[a-z]+example$" does not start at a domain label`,
+		`^[a-z]+\.com$`:           `literal suffix "\\.com" of pattern "^[a-z]+\\.com$" is a top-level domain`,
+		`^[a-z]+\.\.example\.com`: `literal suffix "\\.\\.example\\.com" of pattern "^[a-z]+\\.\\.example\\.com" is not a domain`,
+	}
+
+	for pattern, message := range cases {
+		rewritten, err := RewriteHostRegexpV3(pattern, prepareDefault)
+		assert.EqualError(t, err, message, pattern)
+		assert.Equal(t, pattern, rewritten, pattern)
+	}
+}
@@ -2,127 +2,83 @@ package ingressroute
 
 import (
 	"context"
-	"fmt"
-	"time"
+	"strings"
 
+	"github.com/alehechka/kube-external-sync/client/replicate/certmanager"
 	"github.com/alehechka/kube-external-sync/client/replicate/common"
 	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
-	"github.com/pkg/errors"
-	log "github.com/sirupsen/logrus"
-	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/clientset/versioned"
+	traefikinformers "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/informers/externalversions/traefik/v1alpha1"
 	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
 	"github.com/traefik/traefik/v2/pkg/types"
-	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/watch"
-	"k8s.io/client-go/kubernetes"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 type Replicator struct {
-	*common.GenericReplicator
+	*common.TypedReplicator[*v1alpha1.IngressRoute]
+	Certificates *certmanager.Certificates
 }
 
 // NewReplicator creates a new ingress replicator
-func NewReplicator(ctx context.Context, client kubernetes.Interface, traefik *versioned.Clientset, resyncPeriod time.Duration, defaultIngressHostname string) common.Replicator {
-
-	repl := Replicator{
-		GenericReplicator: common.NewGenericReplicator(ctx, common.ReplicatorConfig{
-			Kind:                   "IngressRoute",
-			ObjType:                &v1alpha1.IngressRoute{},
-			ResyncPeriod:           resyncPeriod,
-			DefaultIngressHostname: defaultIngressHostname,
-			Client:                 client,
-			TraefikClient:          traefik,
-			ListFunc: func(lo metav1.ListOptions) (runtime.Object, error) {
-				return traefik.TraefikV1alpha1().IngressRoutes(v1.NamespaceAll).List(ctx, lo)
-			},
-			WatchFunc: func(lo metav1.ListOptions) (watch.Interface, error) {
-				return traefik.TraefikV1alpha1().IngressRoutes(v1.NamespaceAll).Watch(ctx, lo)
-			},
-		}),
-	}
-	repl.UpdateFuncs = common.UpdateFuncs{
-		ReplicateDataFrom:        repl.ReplicateDataFrom,
-		ReplicateObjectTo:        repl.ReplicateObjectTo,
-		DeleteReplicatedResource: repl.DeleteReplicatedResource,
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer traefikinformers.IngressRouteInformer) common.Replicator {
+	config.Kind = "IngressRoute"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1alpha1.IngressRoute](ctx, config, &repl)
+	if len(config.CertificateIssuer.Name) > 0 {
+		repl.Certificates = certmanager.NewCertificates(repl.GenericReplicator, repl.dnsNames)
 	}
 
 	return &repl
 }
 
-// ReplicateDataFrom takes a source object and copies over data to target object
-func (r *Replicator) ReplicateDataFrom(sourceObj interface{}, targetObj interface{}) error {
-	source := sourceObj.(*v1alpha1.IngressRoute)
-	target := targetObj.(*v1alpha1.IngressRoute)
-
-	logger := log.
-		WithField("kind", r.Kind).
-		WithField("source", common.MustGetKey(source)).
-		WithField("target", common.MustGetKey(target))
-
-	if !common.IsManagedBy(target) {
-		logger.Debugf("target is not managed and will not be synced")
-		return nil
-	}
-
-	targetVersion, ok := target.Annotations[common.ReplicatedFromVersionAnnotation]
-	sourceVersion := source.ResourceVersion
-
-	if ok && targetVersion == sourceVersion {
-		logger.Debugf("target is already up-to-date")
-		return nil
-	}
-
-	prepared := r.prepareIngressRoute(target.Namespace, source)
-	service, err := r.TraefikClient.TraefikV1alpha1().IngressRoutes(target.Namespace).Update(r.Context, prepared, metav1.UpdateOptions{})
-	if err != nil {
-		err = errors.Wrapf(err, "Failed updating target %s", common.MustGetKey(prepared))
-	} else if err = r.Store.Update(service); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s: %v", common.MustGetKey(prepared), err)
-	}
-	return err
+// ResourceClient returns the IngressRoute client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1alpha1.IngressRoute] {
+	return r.TraefikClient.TraefikV1alpha1().IngressRoutes(namespace)
 }
 
-// ReplicateObjectTo copies the whole object to target namespace
-func (r *Replicator) ReplicateObjectTo(sourceObj interface{}, targetNamespace *v1.Namespace) error {
-	source := sourceObj.(*v1alpha1.IngressRoute)
-	sourceKey := common.MustGetKey(source)
-	targetLocation := fmt.Sprintf("%s/%s", targetNamespace.Name, source.Name)
-
-	logger := log.WithField("source", sourceKey).WithField("target", targetLocation).WithField("kind", r.Kind)
-	logger.Infof("Replicating %s to %s", sourceKey, targetNamespace.Name)
-
-	targetResource, err := r.TraefikClient.TraefikV1alpha1().IngressRoutes(targetNamespace.Name).Get(r.Context, source.Name, metav1.GetOptions{})
-	if err == nil && targetResource != nil {
-		return r.ReplicateDataFrom(source, targetResource)
-	}
-
-	prepared := r.prepareIngressRoute(targetNamespace.Name, source)
-	service, err := r.TraefikClient.TraefikV1alpha1().IngressRoutes(targetNamespace.Name).Create(r.Context, prepared, metav1.CreateOptions{})
-	if err != nil {
-		err = errors.Wrapf(err, "Failed creating target %s", common.MustGetKey(prepared))
-	} else if err = r.Store.Update(service); err != nil {
-		err = errors.Wrapf(err, "Failed to update cache for %s: %v", common.MustGetKey(prepared), err)
-	}
-	return err
+// GroupVersionKind is the kind of IngressRoutes
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1alpha1.SchemeGroupVersion.WithKind("IngressRoute")
 }
 
-// DeleteReplicatedResource deletes a resource replicated by ReplicateTo annotation
-func (r *Replicator) DeleteReplicatedResource(targetResource interface{}) error {
-	ingressRoute := targetResource.(*v1alpha1.IngressRoute)
+// References lists the name of the Secret referenced by the TLS configuration, or the names of the Middlewares and
+// TraefikServices in the source namespace referenced by the routes, of the replicas of the source
+func (r *Replicator) References(kind string, sourceObj interface{}) (names []string) {
+	source := sourceObj.(*v1alpha1.IngressRoute)
 
-	if !common.IsManagedBy(ingressRoute) {
-		log.WithField("kind", r.Kind).WithField("target", common.MustGetKey(ingressRoute)).
-			Debugf("target is not managed and will not be deleted")
-		return nil
+	switch kind {
+	case "Secret":
+		if tls := r.prepareTLS(source.Namespace, source, nil); tls != nil && len(tls.SecretName) > 0 && !issuesCertificate(r.ReplicatorConfig, source) {
+			names = append(names, tls.SecretName)
+		}
+	case "TraefikService":
+		for _, route := range source.Spec.Routes {
+			for _, service := range route.Services {
+				if service.Kind == "TraefikService" && isLocalReference(service.Name, service.Namespace) {
+					names = append(names, service.Name)
+				}
+			}
+		}
+	case "Middleware":
+		for _, route := range r.prepareRoutes(source.Namespace, source, nil) {
+			for _, middleware := range route.Middlewares {
+				if isLocalMiddleware(middleware) {
+					names = append(names, middleware.Name)
+				}
+			}
+		}
 	}
 
-	return r.TraefikClient.TraefikV1alpha1().IngressRoutes(ingressRoute.Namespace).Delete(r.Context, ingressRoute.Name, metav1.DeleteOptions{})
+	return
 }
 
-func (r *Replicator) prepareIngressRoute(namespace string, source *v1alpha1.IngressRoute) *v1alpha1.IngressRoute {
-	return &v1alpha1.IngressRoute{
+// Prepare builds the replica of the source IngressRoute with its hosts rewritten for the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1alpha1.IngressRoute) *v1alpha1.IngressRoute {
+	shortened := common.NewHostMapping()
+	prepared := &v1alpha1.IngressRoute{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            source.Name,
 			Namespace:       namespace,
@@ -132,28 +88,31 @@ func (r *Replicator) prepareIngressRoute(namespace string, source *v1alpha1.Ingr
 		},
 		Spec: v1alpha1.IngressRouteSpec{
 			EntryPoints: source.Spec.EntryPoints,
-			Routes:      r.prepareRoutes(namespace, source),
-			TLS:         r.prepareTLS(namespace, source),
+			Routes:      r.prepareRoutes(namespace, source, shortened),
+			TLS:         r.prepareTLS(namespace, source, shortened),
 		},
 	}
+
+	common.SetHostMapping(prepared, shortened)
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
 }
 
-func (r *Replicator) prepareRoutes(namespace string, source *v1alpha1.IngressRoute) (routes []v1alpha1.Route) {
-	annotations := source.GetAnnotations()
-	hostname, ok := annotations[common.TopLevelDomain]
-	if !ok && r.HasDefaultIngressHostname() {
-		hostname = r.DefaultIngressHostname
-	}
+func (r *Replicator) prepareRoutes(namespace string, source *v1alpha1.IngressRoute, shortened *common.HostMapping) (routes []v1alpha1.Route) {
+	hostname := traefik.RouteHostname(r.GenericReplicator, source)
 
 	for _, route := range source.Spec.Routes {
 		newRoute := v1alpha1.Route{
 			Kind:        route.Kind,
-			Middlewares: route.Middlewares,
+			Middlewares: prepareMiddlewares(source, route.Middlewares),
 			Services:    route.Services,
 			Priority:    route.Priority,
 		}
 
-		newRoute.Match = traefik.PrepareRouteMatch(namespace, route.Match, hostname)
+		newRoute.Match = traefik.PrepareSyntaxRouteMatch(traefik.RuleSyntaxV2, route.Match, hostname, func(host string) string {
+			return r.PrepareMappedTLD(namespace, source, host, shortened)
+		})
 
 		routes = append(routes, newRoute)
 	}
@@ -161,47 +120,99 @@ func (r *Replicator) prepareRoutes(namespace string, source *v1alpha1.IngressRou
 	return
 }
 
-func (r *Replicator) prepareTLS(namespace string, source *v1alpha1.IngressRoute) *v1alpha1.TLS {
+// prepareMiddlewares points local middleware references to the source namespace if requested by the MiddlewareRefs annotation
+func prepareMiddlewares(source *v1alpha1.IngressRoute, middlewares []v1alpha1.MiddlewareRef) []v1alpha1.MiddlewareRef {
+	if refs, ok := source.Annotations[common.MiddlewareRefs]; !ok || refs != common.MiddlewareRefsSource {
+		return middlewares
+	}
+
+	prepared := make([]v1alpha1.MiddlewareRef, 0, len(middlewares))
+	for _, middleware := range middlewares {
+		if isLocalMiddleware(middleware) {
+			middleware.Namespace = source.Namespace
+		}
+		prepared = append(prepared, middleware)
+	}
+
+	return prepared
+}
+
+// isLocalMiddleware checks whether the middleware reference resolves to a Middleware in the namespace of the IngressRoute.
+// References to other providers, such as "auth@file", are never local.
+func isLocalMiddleware(middleware v1alpha1.MiddlewareRef) bool {
+	return isLocalReference(middleware.Name, middleware.Namespace)
+}
+
+// isLocalReference checks whether a reference to a Middleware or TraefikService resolves to the namespace of the
+// IngressRoute. References to other providers, such as "whoami@file", are never local.
+func isLocalReference(name, namespace string) bool {
+	return len(namespace) == 0 && !strings.Contains(name, "@")
+}
+
+// issuesCertificate checks whether a Certificate is issued for the replicas of the source, unless Traefik resolves
+// their certificates itself
+func issuesCertificate(config common.ReplicatorConfig, source *v1alpha1.IngressRoute) bool {
+	return source.Spec.TLS != nil && len(source.Spec.TLS.CertResolver) == 0 && certmanager.Issues(config, source)
+}
+
+// dnsNames lists the TLS domains of the replica of the source that a Certificate is issued for
+func (r *Replicator) dnsNames(namespace string, sourceObj interface{}) (names []string) {
+	source := sourceObj.(*v1alpha1.IngressRoute)
+	if !issuesCertificate(r.ReplicatorConfig, source) {
+		return nil
+	}
+
+	for _, domain := range r.prepareTLS(namespace, source, nil).Domains {
+		names = append(names, domain.Main)
+		names = append(names, domain.SANs...)
+	}
+
+	return
+}
+
+func (r *Replicator) prepareTLS(namespace string, source *v1alpha1.IngressRoute, shortened *common.HostMapping) *v1alpha1.TLS {
 	if source.Spec.TLS == nil {
 		return nil
 	}
 
 	annotations := source.GetAnnotations()
 
+	secretName := annotations[common.TLDSecretName]
+	if issuesCertificate(r.ReplicatorConfig, source) {
+		secretName = certmanager.CertificateName(r.Kind, source.Name)
+	}
+
 	tls := &v1alpha1.TLS{
-		SecretName:   annotations[common.TLDSecretName],
+		SecretName:   secretName,
 		Options:      source.Spec.TLS.Options,
 		Store:        source.Spec.TLS.Store,
 		CertResolver: source.Spec.TLS.CertResolver,
 	}
 
-	if tld, ok := annotations[common.TopLevelDomain]; ok {
+	if hostname := traefik.RouteHostname(r.GenericReplicator, source); len(hostname) > 0 {
 		tls.Domains = []types.Domain{{
-			Main: common.PrepareTLD(namespace, tld),
+			Main: r.PrepareMappedTLD(namespace, source, hostname, shortened),
 		}}
 
 		return tls
 	}
 
-	if r.HasDefaultIngressHostname() {
-		tls.Domains = []types.Domain{{
-			Main: common.PrepareTLD(namespace, r.DefaultIngressHostname),
-		}}
-
-		return tls
-	}
-
-	tls.Domains = prepareDomains(namespace, source)
+	tls.Domains = r.prepareDomains(namespace, source, shortened)
 	return tls
 }
 
-func prepareDomains(namespace string, source *v1alpha1.IngressRoute) (domains []types.Domain) {
+func (r *Replicator) prepareDomains(namespace string, source *v1alpha1.IngressRoute, shortened *common.HostMapping) (domains []types.Domain) {
 	for _, domain := range source.Spec.TLS.Domains {
-		newDomain := types.Domain{Main: common.PrepareTLD(namespace, domain.Main)}
+		newDomain := types.Domain{Main: r.PrepareMappedTLD(namespace, source, domain.Main, shortened)}
 		for _, san := range domain.SANs {
-			newDomain.SANs = append(newDomain.SANs, common.PrepareTLD(namespace, san))
+			newDomain.SANs = append(newDomain.SANs, r.PrepareMappedTLD(namespace, source, san, shortened))
 		}
 		domains = append(domains, newDomain)
 	}
 	return
 }
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1alpha1.IngressRoute, target *v1alpha1.IngressRoute) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
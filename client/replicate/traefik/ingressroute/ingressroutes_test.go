@@ -0,0 +1,82 @@
+package ingressroute
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testIngressRoute(middlewareRefs string) *v1alpha1.IngressRoute {
+	ingressRoute := &v1alpha1.IngressRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "nginx",
+			Namespace:   "default",
+			Annotations: map[string]string{common.ReplicateTo: "feature-.*"},
+		},
+		Spec: v1alpha1.IngressRouteSpec{
+			Routes: []v1alpha1.Route{{
+				Kind:  "Rule",
+				Match: "Host(`nginx.example.com`)",
+				Middlewares: []v1alpha1.MiddlewareRef{
+					{Name: "strip-prefix"},
+					{Name: "headers", Namespace: "traefik"},
+					{Name: "auth@file"},
+				},
+			}},
+		},
+	}
+
+	if len(middlewareRefs) > 0 {
+		ingressRoute.Annotations[common.MiddlewareRefs] = middlewareRefs
+	}
+
+	return ingressRoute
+}
+
+func Test_PrepareMiddlewares(t *testing.T) {
+	tests := []struct {
+		middlewareRefs string
+		expected       []v1alpha1.MiddlewareRef
+		references     []string
+	}{
+		{
+			middlewareRefs: "",
+			expected: []v1alpha1.MiddlewareRef{
+				{Name: "strip-prefix"},
+				{Name: "headers", Namespace: "traefik"},
+				{Name: "auth@file"},
+			},
+			references: []string{"strip-prefix"},
+		},
+		{
+			middlewareRefs: common.MiddlewareRefsLocal,
+			expected: []v1alpha1.MiddlewareRef{
+				{Name: "strip-prefix"},
+				{Name: "headers", Namespace: "traefik"},
+				{Name: "auth@file"},
+			},
+			references: []string{"strip-prefix"},
+		},
+		{
+			middlewareRefs: common.MiddlewareRefsSource,
+			expected: []v1alpha1.MiddlewareRef{
+				{Name: "strip-prefix", Namespace: "default"},
+				{Name: "headers", Namespace: "traefik"},
+				{Name: "auth@file"},
+			},
+		},
+	}
+
+	r := &Replicator{TypedReplicator: &common.TypedReplicator[*v1alpha1.IngressRoute]{GenericReplicator: &common.GenericReplicator{}}}
+	for _, test := range tests {
+		t.Run(test.middlewareRefs, func(t *testing.T) {
+			source := testIngressRoute(test.middlewareRefs)
+
+			assert.Equal(t, test.expected, prepareMiddlewares(source, source.Spec.Routes[0].Middlewares))
+			assert.Equal(t, test.references, r.References("Middleware", source))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package ingressroute
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/certmanager"
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+// UnstructuredReplicator replicates the IngressRoutes of the traefik.io API group, which the Traefik clientset does
+// not know, with the dynamic client. All fields of the source are copied, so that fields added by Traefik v3 are kept.
+type UnstructuredReplicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+	Certificates *certmanager.Certificates
+}
+
+// NewUnstructuredReplicator creates a new traefik.io IngressRoute replicator
+func NewUnstructuredReplicator(ctx context.Context, config common.ReplicatorConfig, informer informers.GenericInformer) common.Replicator {
+	config.Kind = "IngressRoute"
+	config.Informer = informer.Informer()
+
+	repl := UnstructuredReplicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+	if len(config.CertificateIssuer.Name) > 0 {
+		repl.Certificates = certmanager.NewCertificates(repl.GenericReplicator, repl.dnsNames)
+	}
+
+	return &repl
+}
+
+// ResourceClient returns the dynamic traefik.io IngressRoute client of the provided namespace
+func (r *UnstructuredReplicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(traefik.IngressRoutes).Namespace(namespace))
+}
+
+// GroupVersionKind is the kind of traefik.io IngressRoutes
+func (r *UnstructuredReplicator) GroupVersionKind() schema.GroupVersionKind {
+	return traefik.GroupVersion.WithKind("IngressRoute")
+}
+
+// References lists the name of the Secret referenced by the TLS configuration, or the names of the Middlewares and
+// TraefikServices in the source namespace referenced by the routes, of the replicas of the source
+func (r *UnstructuredReplicator) References(kind string, sourceObj interface{}) (names []string) {
+	source := sourceObj.(*unstructured.Unstructured)
+
+	switch kind {
+	case "Secret":
+		if _, ok := traefik.TLSOf(source); ok && !r.issuesCertificate(source) {
+			if secretName := source.GetAnnotations()[common.TLDSecretName]; len(secretName) > 0 {
+				names = append(names, secretName)
+			}
+		}
+	case "TraefikService":
+		for _, route := range traefik.RoutesOf(source) {
+			for _, service := range traefik.MapsOf(route["services"]) {
+				name, namespace := referenceOf(service)
+				if service["kind"] == "TraefikService" && isLocalReference(name, namespace) {
+					names = append(names, name)
+				}
+			}
+		}
+	case "Middleware":
+		if source.GetAnnotations()[common.MiddlewareRefs] == common.MiddlewareRefsSource {
+			return nil
+		}
+		for _, route := range traefik.RoutesOf(source) {
+			for _, middleware := range traefik.MapsOf(route["middlewares"]) {
+				if name, namespace := referenceOf(middleware); isLocalReference(name, namespace) {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// Prepare builds the replica of the source IngressRoute with the hosts of its rules and TLS domains rewritten for
+// the provided namespace. Rules are rewritten in the syntax set by their route or the configured TraefikRuleSyntax.
+func (r *UnstructuredReplicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.GroupVersionKind(), source)
+	shortened := common.NewHostMapping()
+	hostname := traefik.RouteHostname(r.GenericReplicator, source)
+	prepareTLD := func(host string) string {
+		return r.PrepareMappedTLD(namespace, source, host, shortened)
+	}
+
+	traefik.PrepareUnstructuredRoutes(prepared, r.TraefikRuleSyntax, hostname, prepareTLD)
+
+	if source.GetAnnotations()[common.MiddlewareRefs] == common.MiddlewareRefsSource {
+		for _, route := range traefik.RoutesOf(prepared) {
+			for _, middleware := range traefik.MapsOf(route["middlewares"]) {
+				if name, refNamespace := referenceOf(middleware); isLocalReference(name, refNamespace) {
+					middleware["namespace"] = source.GetNamespace()
+				}
+			}
+		}
+	}
+
+	if tls, ok := traefik.TLSOf(prepared); ok {
+		delete(tls, "secretName")
+		if secretName := r.tlsSecretName(source); len(secretName) > 0 {
+			tls["secretName"] = secretName
+		}
+
+		delete(tls, "domains")
+		if domains := traefik.PrepareUnstructuredDomains(source, hostname, prepareTLD); len(domains) > 0 {
+			tls["domains"] = domains
+		}
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// issuesCertificate checks whether a Certificate is issued for the replicas of the source, unless Traefik resolves
+// their certificates itself
+func (r *UnstructuredReplicator) issuesCertificate(source *unstructured.Unstructured) bool {
+	tls, ok := traefik.TLSOf(source)
+	if !ok {
+		return false
+	}
+
+	certResolver, _ := tls["certResolver"].(string)
+	return len(certResolver) == 0 && certmanager.Issues(r.ReplicatorConfig, source)
+}
+
+// tlsSecretName is the name of the Secret of the TLS configuration of the replicas of the source
+func (r *UnstructuredReplicator) tlsSecretName(source *unstructured.Unstructured) string {
+	if r.issuesCertificate(source) {
+		return certmanager.CertificateName(r.Kind, source.GetName())
+	}
+
+	return source.GetAnnotations()[common.TLDSecretName]
+}
+
+// dnsNames lists the TLS domains of the replica of the source that a Certificate is issued for
+func (r *UnstructuredReplicator) dnsNames(namespace string, sourceObj interface{}) (names []string) {
+	source := sourceObj.(*unstructured.Unstructured)
+	if !r.issuesCertificate(source) {
+		return nil
+	}
+
+	domains := traefik.PrepareUnstructuredDomains(source, traefik.RouteHostname(r.GenericReplicator, source), func(host string) string {
+		return r.PrepareMappedTLD(namespace, source, host, nil)
+	})
+	for _, domain := range traefik.MapsOf(domains) {
+		if main, ok := domain["main"].(string); ok {
+			names = append(names, main)
+		}
+		for _, san := range traefik.SliceOf(domain["sans"]) {
+			if san, ok := san.(string); ok {
+				names = append(names, san)
+			}
+		}
+	}
+
+	return
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *UnstructuredReplicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
+
+// referenceOf returns the name and namespace of a reference to a Middleware or service
+func referenceOf(reference map[string]interface{}) (name, namespace string) {
+	name, _ = reference["name"].(string)
+	namespace, _ = reference["namespace"].(string)
+	return
+}
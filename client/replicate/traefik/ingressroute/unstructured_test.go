@@ -0,0 +1,132 @@
+package ingressroute
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestUnstructuredReplicator() *UnstructuredReplicator {
+	return &UnstructuredReplicator{TypedReplicator: &common.TypedReplicator[*unstructured.Unstructured]{
+		GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{
+			Kind:              "IngressRoute",
+			TraefikRuleSyntax: traefik.RuleSyntaxV3,
+		}},
+	}}
+}
+
+func testUnstructuredIngressRoute(annotations map[string]interface{}) *unstructured.Unstructured {
+	annotations[common.ReplicateTo] = "feature-.*"
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "traefik.io/v1alpha1",
+		"kind":       "IngressRoute",
+		"metadata": map[string]interface{}{
+			"name":        "nginx",
+			"namespace":   "default",
+			"annotations": annotations,
+		},
+		"spec": map[string]interface{}{
+			"entryPoints": []interface{}{"websecure"},
+			"routes": []interface{}{
+				map[string]interface{}{
+					"kind":  "Rule",
+					"match": "HostRegexp(`^[a-z]+\\.example\\.com$`)",
+					"middlewares": []interface{}{
+						map[string]interface{}{"name": "strip-prefix"},
+						map[string]interface{}{"name": "auth@file"},
+					},
+					"services": []interface{}{
+						map[string]interface{}{"kind": "TraefikService", "name": "weighted"},
+						map[string]interface{}{"name": "nginx", "port": int64(80)},
+					},
+					"observability": map[string]interface{}{"accessLogs": false},
+				},
+				map[string]interface{}{
+					"kind":   "Rule",
+					"match":  "HostRegexp(`{subdomain:[a-z]+}.example.com`)",
+					"syntax": traefik.RuleSyntaxV2,
+				},
+			},
+			"tls": map[string]interface{}{
+				"secretName": "nginx-tls",
+				"domains": []interface{}{
+					map[string]interface{}{"main": "nginx.example.com", "sans": []interface{}{"www.example.com"}},
+				},
+			},
+		},
+	}}
+}
+
+func Test_UnstructuredReplicator_Prepare(t *testing.T) {
+	r := newTestUnstructuredReplicator()
+	source := testUnstructuredIngressRoute(map[string]interface{}{})
+	prepared := r.Prepare("feature-a", source)
+
+	assert.Equal(t, "feature-a", prepared.GetNamespace())
+	assert.Equal(t, "traefik.io/v1alpha1", prepared.GetAPIVersion())
+	assert.True(t, common.IsManagedBy(prepared))
+
+	routes := traefik.RoutesOf(prepared)
+	assert.Equal(t, "HostRegexp(`^[a-z]+\\.feature-a\\.example\\.com$`)", routes[0]["match"])
+	assert.Equal(t, "HostRegexp(`{subdomain:[a-z]+}.feature-a.example.com`)", routes[1]["match"])
+	assert.Equal(t, map[string]interface{}{"accessLogs": false}, routes[0]["observability"])
+	assert.Equal(t, []map[string]interface{}{{"name": "strip-prefix"}, {"name": "auth@file"}}, traefik.MapsOf(routes[0]["middlewares"]))
+
+	tls, _ := traefik.TLSOf(prepared)
+	assert.NotContains(t, tls, "secretName")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"main": "feature-a.example.com", "sans": []interface{}{"feature-a.example.com"}},
+	}, tls["domains"])
+
+	// the source is not modified
+	assert.Equal(t, "HostRegexp(`^[a-z]+\\.example\\.com$`)", traefik.RoutesOf(source)[0]["match"])
+}
+
+func Test_UnstructuredReplicator_Prepare_TopLevelDomain(t *testing.T) {
+	r := newTestUnstructuredReplicator()
+	source := testUnstructuredIngressRoute(map[string]interface{}{
+		common.TopLevelDomain: "*.other.com",
+		common.TLDSecretName:  "wildcard-tls",
+		common.MiddlewareRefs: common.MiddlewareRefsSource,
+	})
+	prepared := r.Prepare("feature-a", source)
+
+	routes := traefik.RoutesOf(prepared)
+	assert.Equal(t, "HostRegexp(`^[a-z]+\\.feature-a\\.other\\.com$`)", routes[0]["match"])
+	assert.Equal(t, []map[string]interface{}{{"name": "strip-prefix", "namespace": "default"}, {"name": "auth@file"}}, traefik.MapsOf(routes[0]["middlewares"]))
+
+	tls, _ := traefik.TLSOf(prepared)
+	assert.Equal(t, "wildcard-tls", tls["secretName"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"main": "feature-a.other.com"}}, tls["domains"])
+}
+
+func Test_UnstructuredReplicator_Prepare_ShortenedHosts(t *testing.T) {
+	r := newTestUnstructuredReplicator()
+	namespace := "feature-a-very-long-branch-name-that-exceeds-the-dns-label-limit"
+	source := testUnstructuredIngressRoute(map[string]interface{}{common.ShortenedHostsAnnotation: "stale"})
+
+	prepared := r.Prepare(namespace, source)
+	assert.Equal(t, "HostRegexp(`^[a-z]+\\.feature-a-very-long-branch-name-that-exceeds-the-dns-l-05e5f97c\\.example\\.com$`)", traefik.RoutesOf(prepared)[0]["match"])
+	assert.Equal(t, namespace+".example.com=feature-a-very-long-branch-name-that-exceeds-the-dns-l-05e5f97c.example.com",
+		prepared.GetAnnotations()[common.ShortenedHostsAnnotation])
+
+	prepared = r.Prepare("feature-a", source)
+	assert.NotContains(t, prepared.GetAnnotations(), common.ShortenedHostsAnnotation)
+}
+
+func Test_UnstructuredReplicator_References(t *testing.T) {
+	r := newTestUnstructuredReplicator()
+
+	source := testUnstructuredIngressRoute(map[string]interface{}{common.TLDSecretName: "wildcard-tls"})
+	assert.Equal(t, []string{"wildcard-tls"}, r.References("Secret", source))
+	assert.Equal(t, []string{"weighted"}, r.References("TraefikService", source))
+	assert.Equal(t, []string{"strip-prefix"}, r.References("Middleware", source))
+
+	source = testUnstructuredIngressRoute(map[string]interface{}{common.MiddlewareRefs: common.MiddlewareRefsSource})
+	assert.Empty(t, r.References("Secret", source))
+	assert.Empty(t, r.References("Middleware", source))
+}
@@ -0,0 +1,143 @@
+package ingressroutetcp
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	traefikinformers "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/informers/externalversions/traefik/v1alpha1"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	"github.com/traefik/traefik/v2/pkg/types"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1alpha1.IngressRouteTCP]
+}
+
+// NewReplicator creates a new IngressRouteTCP replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer traefikinformers.IngressRouteTCPInformer) common.Replicator {
+	config.Kind = "IngressRouteTCP"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1alpha1.IngressRouteTCP](ctx, config, &repl)
+
+	return &repl
+}
+
+// ResourceClient returns the IngressRouteTCP client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1alpha1.IngressRouteTCP] {
+	return r.TraefikClient.TraefikV1alpha1().IngressRouteTCPs(namespace)
+}
+
+// GroupVersionKind is the kind of IngressRouteTCPs
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1alpha1.SchemeGroupVersion.WithKind("IngressRouteTCP")
+}
+
+// References lists the name of the Secret referenced by the TLS configuration of the replicas of the source
+func (r *Replicator) References(kind string, sourceObj interface{}) []string {
+	if kind != "Secret" {
+		return nil
+	}
+
+	source := sourceObj.(*v1alpha1.IngressRouteTCP)
+	if tls := r.prepareTLS(source.Namespace, source, nil); tls != nil && len(tls.SecretName) > 0 {
+		return []string{tls.SecretName}
+	}
+
+	return nil
+}
+
+// Prepare builds the replica of the source IngressRouteTCP with its SNI hosts rewritten for the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1alpha1.IngressRouteTCP) *v1alpha1.IngressRouteTCP {
+	shortened := common.NewHostMapping()
+	prepared := &v1alpha1.IngressRouteTCP{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Spec: v1alpha1.IngressRouteTCPSpec{
+			EntryPoints: source.Spec.EntryPoints,
+			Routes:      r.prepareRoutes(namespace, source, shortened),
+			TLS:         r.prepareTLS(namespace, source, shortened),
+		},
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+func (r *Replicator) prepareRoutes(namespace string, source *v1alpha1.IngressRouteTCP, shortened *common.HostMapping) (routes []v1alpha1.RouteTCP) {
+	hostname := traefik.RouteHostname(r.GenericReplicator, source)
+	for _, route := range source.Spec.Routes {
+		routes = append(routes, v1alpha1.RouteTCP{
+			Match: traefik.PrepareSyntaxRouteMatch(traefik.RuleSyntaxV2, route.Match, hostname, func(host string) string {
+				return r.PrepareMappedTLD(namespace, source, host, shortened)
+			}),
+			Priority:    route.Priority,
+			Services:    route.Services,
+			Middlewares: route.Middlewares,
+		})
+	}
+
+	return
+}
+
+func (r *Replicator) prepareTLS(namespace string, source *v1alpha1.IngressRouteTCP, shortened *common.HostMapping) *v1alpha1.TLSTCP {
+	if source.Spec.TLS == nil {
+		return nil
+	}
+
+	annotations := source.GetAnnotations()
+
+	tls := &v1alpha1.TLSTCP{
+		SecretName:   source.Spec.TLS.SecretName,
+		Passthrough:  source.Spec.TLS.Passthrough,
+		Options:      source.Spec.TLS.Options,
+		Store:        source.Spec.TLS.Store,
+		CertResolver: source.Spec.TLS.CertResolver,
+	}
+
+	if secretName, ok := annotations[common.TLDSecretName]; ok {
+		tls.SecretName = secretName
+	}
+
+	if tld, ok := annotations[common.TopLevelDomain]; ok {
+		tls.Domains = []types.Domain{{
+			Main: r.PrepareMappedTLD(namespace, source, tld, shortened),
+		}}
+
+		return tls
+	}
+
+	if r.HasDefaultIngressHostname() {
+		tls.Domains = []types.Domain{{
+			Main: r.PrepareMappedTLD(namespace, source, r.DefaultIngressHostname, shortened),
+		}}
+
+		return tls
+	}
+
+	for _, domain := range source.Spec.TLS.Domains {
+		newDomain := types.Domain{Main: r.PrepareMappedTLD(namespace, source, domain.Main, shortened)}
+		for _, san := range domain.SANs {
+			newDomain.SANs = append(newDomain.SANs, r.PrepareMappedTLD(namespace, source, san, shortened))
+		}
+		tls.Domains = append(tls.Domains, newDomain)
+	}
+
+	return tls
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1alpha1.IngressRouteTCP, target *v1alpha1.IngressRouteTCP) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
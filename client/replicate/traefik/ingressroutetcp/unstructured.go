@@ -0,0 +1,99 @@
+package ingressroutetcp
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+// UnstructuredReplicator replicates the IngressRouteTCPs of the traefik.io API group with the dynamic client
+type UnstructuredReplicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+}
+
+// NewUnstructuredReplicator creates a new traefik.io IngressRouteTCP replicator
+func NewUnstructuredReplicator(ctx context.Context, config common.ReplicatorConfig, informer informers.GenericInformer) common.Replicator {
+	config.Kind = "IngressRouteTCP"
+	config.Informer = informer.Informer()
+
+	repl := UnstructuredReplicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+
+	return &repl
+}
+
+// ResourceClient returns the dynamic traefik.io IngressRouteTCP client of the provided namespace
+func (r *UnstructuredReplicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(traefik.IngressRouteTCPs).Namespace(namespace))
+}
+
+// GroupVersionKind is the kind of traefik.io IngressRouteTCPs
+func (r *UnstructuredReplicator) GroupVersionKind() schema.GroupVersionKind {
+	return traefik.GroupVersion.WithKind("IngressRouteTCP")
+}
+
+// References lists the name of the Secret referenced by the TLS configuration of the replicas of the source
+func (r *UnstructuredReplicator) References(kind string, sourceObj interface{}) []string {
+	if kind != "Secret" {
+		return nil
+	}
+
+	if secretName := tlsSecretName(sourceObj.(*unstructured.Unstructured)); len(secretName) > 0 {
+		return []string{secretName}
+	}
+
+	return nil
+}
+
+// Prepare builds the replica of the source IngressRouteTCP with its SNI hosts and TLS domains rewritten for the
+// provided namespace. Rules are rewritten in the syntax set by their route or the configured TraefikRuleSyntax.
+func (r *UnstructuredReplicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.GroupVersionKind(), source)
+	shortened := common.NewHostMapping()
+	hostname := traefik.RouteHostname(r.GenericReplicator, source)
+	prepareTLD := func(host string) string {
+		return r.PrepareMappedTLD(namespace, source, host, shortened)
+	}
+
+	traefik.PrepareUnstructuredRoutes(prepared, r.TraefikRuleSyntax, hostname, prepareTLD)
+
+	if tls, ok := traefik.TLSOf(prepared); ok {
+		delete(tls, "secretName")
+		if secretName := tlsSecretName(source); len(secretName) > 0 {
+			tls["secretName"] = secretName
+		}
+
+		delete(tls, "domains")
+		if domains := traefik.PrepareUnstructuredDomains(source, hostname, prepareTLD); len(domains) > 0 {
+			tls["domains"] = domains
+		}
+	}
+
+	common.SetHostMapping(prepared, shortened)
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// tlsSecretName is the name of the Secret of the TLS configuration of the replicas of the source
+func tlsSecretName(source *unstructured.Unstructured) string {
+	tls, ok := traefik.TLSOf(source)
+	if !ok {
+		return ""
+	}
+
+	if secretName, ok := source.GetAnnotations()[common.TLDSecretName]; ok {
+		return secretName
+	}
+
+	secretName, _ := tls["secretName"].(string)
+	return secretName
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *UnstructuredReplicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
@@ -0,0 +1,63 @@
+package ingressroutetcp
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testUnstructuredIngressRouteTCP(annotations map[string]interface{}) *unstructured.Unstructured {
+	annotations[common.ReplicateTo] = "feature-.*"
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "traefik.io/v1alpha1",
+		"kind":       "IngressRouteTCP",
+		"metadata": map[string]interface{}{
+			"name":        "postgres",
+			"namespace":   "default",
+			"annotations": annotations,
+		},
+		"spec": map[string]interface{}{
+			"entryPoints": []interface{}{"postgres"},
+			"routes": []interface{}{
+				map[string]interface{}{
+					"match":    "HostSNIRegexp(`^[a-z]+\\.example\\.com$`)",
+					"services": []interface{}{map[string]interface{}{"name": "postgres", "port": int64(5432)}},
+				},
+				map[string]interface{}{
+					"match":  "HostSNI(`db.example.com`)",
+					"syntax": traefik.RuleSyntaxV2,
+				},
+			},
+			"tls": map[string]interface{}{
+				"secretName":  "postgres-tls",
+				"passthrough": true,
+			},
+		},
+	}}
+}
+
+func Test_UnstructuredReplicator_Prepare(t *testing.T) {
+	r := &UnstructuredReplicator{TypedReplicator: &common.TypedReplicator[*unstructured.Unstructured]{
+		GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{
+			Kind:              "IngressRouteTCP",
+			TraefikRuleSyntax: traefik.RuleSyntaxV3,
+		}},
+	}}
+
+	prepared := r.Prepare("feature-a", testUnstructuredIngressRouteTCP(map[string]interface{}{}))
+
+	routes := traefik.RoutesOf(prepared)
+	assert.Equal(t, "HostSNIRegexp(`^[a-z]+\\.feature-a\\.example\\.com$`)", routes[0]["match"])
+	assert.Equal(t, "HostSNI(`feature-a.example.com`)", routes[1]["match"])
+
+	tls, _ := traefik.TLSOf(prepared)
+	assert.Equal(t, "postgres-tls", tls["secretName"])
+	assert.Equal(t, true, tls["passthrough"])
+
+	assert.Equal(t, []string{"postgres-tls"}, r.References("Secret", testUnstructuredIngressRouteTCP(map[string]interface{}{})))
+	assert.Empty(t, r.References("Middleware", testUnstructuredIngressRouteTCP(map[string]interface{}{})))
+}
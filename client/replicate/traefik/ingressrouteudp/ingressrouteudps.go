@@ -0,0 +1,59 @@
+package ingressrouteudp
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	traefikinformers "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/informers/externalversions/traefik/v1alpha1"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1alpha1.IngressRouteUDP]
+}
+
+// NewReplicator creates a new IngressRouteUDP replicator
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer traefikinformers.IngressRouteUDPInformer) common.Replicator {
+	config.Kind = "IngressRouteUDP"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1alpha1.IngressRouteUDP](ctx, config, &repl)
+
+	return &repl
+}
+
+// ResourceClient returns the IngressRouteUDP client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1alpha1.IngressRouteUDP] {
+	return r.TraefikClient.TraefikV1alpha1().IngressRouteUDPs(namespace)
+}
+
+// GroupVersionKind is the kind of IngressRouteUDPs
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1alpha1.SchemeGroupVersion.WithKind("IngressRouteUDP")
+}
+
+// Prepare builds the replica of the source IngressRouteUDP in the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1alpha1.IngressRouteUDP) *v1alpha1.IngressRouteUDP {
+	prepared := &v1alpha1.IngressRouteUDP{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Spec: source.Spec,
+	}
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1alpha1.IngressRouteUDP, target *v1alpha1.IngressRouteUDP) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
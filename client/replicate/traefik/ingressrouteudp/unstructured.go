@@ -0,0 +1,50 @@
+package ingressrouteudp
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+// UnstructuredReplicator replicates the IngressRouteUDPs of the traefik.io API group with the dynamic client
+type UnstructuredReplicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+}
+
+// NewUnstructuredReplicator creates a new traefik.io IngressRouteUDP replicator
+func NewUnstructuredReplicator(ctx context.Context, config common.ReplicatorConfig, informer informers.GenericInformer) common.Replicator {
+	config.Kind = "IngressRouteUDP"
+	config.Informer = informer.Informer()
+
+	repl := UnstructuredReplicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+
+	return &repl
+}
+
+// ResourceClient returns the dynamic traefik.io IngressRouteUDP client of the provided namespace
+func (r *UnstructuredReplicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(traefik.IngressRouteUDPs).Namespace(namespace))
+}
+
+// GroupVersionKind is the kind of traefik.io IngressRouteUDPs
+func (r *UnstructuredReplicator) GroupVersionKind() schema.GroupVersionKind {
+	return traefik.GroupVersion.WithKind("IngressRouteUDP")
+}
+
+// Prepare builds the replica of the source IngressRouteUDP in the provided namespace
+func (r *UnstructuredReplicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.GroupVersionKind(), source)
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *UnstructuredReplicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
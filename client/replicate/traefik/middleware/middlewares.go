@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	traefikinformers "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/informers/externalversions/traefik/v1alpha1"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1alpha1.Middleware]
+}
+
+// NewReplicator creates a new middleware replicator. Middlewares are replicated alongside the replicas of the
+// provided replicators and chain Middlewares that reference them, as well as by their own ReplicateTo or
+// ReplicateToMatching annotations.
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer traefikinformers.MiddlewareInformer, referencing ...common.Replicator) common.Replicator {
+	config.Kind = "Middleware"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1alpha1.Middleware](ctx, config, &repl)
+	repl.ReplicateReferencedBy(append(referencing, &repl)...)
+
+	return &repl
+}
+
+// ResourceClient returns the Middleware client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1alpha1.Middleware] {
+	return r.TraefikClient.TraefikV1alpha1().Middlewares(namespace)
+}
+
+// GroupVersionKind is the kind of Middlewares
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1alpha1.SchemeGroupVersion.WithKind("Middleware")
+}
+
+// References lists the names of the Middlewares in the source namespace referenced by a chain Middleware, or the name
+// of the client certificate Secret of a forwardAuth Middleware. The Secrets of basicAuth and digestAuth Middlewares and
+// the Service of errors Middlewares are not TLS Secrets or replicated alongside the Middleware, and have to be
+// replicated with their own ReplicateTo or ReplicateToMatching annotations.
+func (r *Replicator) References(kind string, sourceObj interface{}) (names []string) {
+	source := sourceObj.(*v1alpha1.Middleware)
+
+	switch kind {
+	case "Middleware":
+		if source.Spec.Chain == nil {
+			return nil
+		}
+		for _, middleware := range source.Spec.Chain.Middlewares {
+			if isLocalReference(middleware.Name, middleware.Namespace) {
+				names = append(names, middleware.Name)
+			}
+		}
+	case "Secret":
+		if forwardAuth := source.Spec.ForwardAuth; forwardAuth != nil && forwardAuth.TLS != nil && len(forwardAuth.TLS.CertSecret) > 0 {
+			names = append(names, forwardAuth.TLS.CertSecret)
+		}
+	}
+
+	return
+}
+
+// Prepare builds the replica of the source Middleware in the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1alpha1.Middleware) *v1alpha1.Middleware {
+	prepared := &v1alpha1.Middleware{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Spec: source.Spec,
+	}
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1alpha1.Middleware, target *v1alpha1.Middleware) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
+
+// isLocalReference checks whether a reference to a Middleware resolves to the namespace of the Middleware.
+// References to other providers, such as "auth@file", are never local.
+func isLocalReference(name, namespace string) bool {
+	return len(namespace) == 0 && !strings.Contains(name, "@")
+}
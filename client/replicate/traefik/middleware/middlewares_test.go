@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_Replicator_References(t *testing.T) {
+	r := &Replicator{TypedReplicator: &common.TypedReplicator[*v1alpha1.Middleware]{
+		GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "Middleware"}},
+	}}
+
+	chain := &v1alpha1.Middleware{
+		ObjectMeta: metav1.ObjectMeta{Name: "chain", Namespace: "default"},
+		Spec: v1alpha1.MiddlewareSpec{Chain: &v1alpha1.Chain{Middlewares: []v1alpha1.MiddlewareRef{
+			{Name: "strip-prefix"},
+			{Name: "shared", Namespace: "shared"},
+			{Name: "auth@file"},
+		}}},
+	}
+	assert.Equal(t, []string{"strip-prefix"}, r.References("Middleware", chain))
+	assert.Empty(t, r.References("Secret", chain))
+
+	forwardAuth := &v1alpha1.Middleware{
+		ObjectMeta: metav1.ObjectMeta{Name: "auth", Namespace: "default"},
+		Spec: v1alpha1.MiddlewareSpec{ForwardAuth: &v1alpha1.ForwardAuth{
+			Address: "https://auth.example.com",
+			TLS:     &v1alpha1.ClientTLS{CertSecret: "auth-client-tls"},
+		}},
+	}
+	assert.Equal(t, []string{"auth-client-tls"}, r.References("Secret", forwardAuth))
+	assert.Empty(t, r.References("Middleware", forwardAuth))
+}
+
+func Test_UnstructuredReplicator_References(t *testing.T) {
+	r := &UnstructuredReplicator{TypedReplicator: &common.TypedReplicator[*unstructured.Unstructured]{
+		GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "Middleware"}},
+	}}
+
+	chain := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "traefik.io/v1alpha1",
+		"kind":       "Middleware",
+		"metadata":   map[string]interface{}{"name": "chain", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"chain": map[string]interface{}{"middlewares": []interface{}{
+				map[string]interface{}{"name": "strip-prefix"},
+				map[string]interface{}{"name": "shared", "namespace": "shared"},
+				map[string]interface{}{"name": "auth@file"},
+			}},
+			"forwardAuth": map[string]interface{}{
+				"address": "https://auth.example.com",
+				"tls":     map[string]interface{}{"certSecret": "auth-client-tls"},
+			},
+		},
+	}}
+	assert.Equal(t, []string{"strip-prefix"}, r.References("Middleware", chain))
+	assert.Equal(t, []string{"auth-client-tls"}, r.References("Secret", chain))
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+// UnstructuredReplicator replicates the Middlewares of the traefik.io API group with the dynamic client
+type UnstructuredReplicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+}
+
+// NewUnstructuredReplicator creates a new traefik.io Middleware replicator. Middlewares are replicated alongside the
+// replicas of the provided replicators and chain Middlewares that reference them, as well as by their own ReplicateTo
+// or ReplicateToMatching annotations.
+func NewUnstructuredReplicator(ctx context.Context, config common.ReplicatorConfig, informer informers.GenericInformer, referencing ...common.Replicator) common.Replicator {
+	config.Kind = "Middleware"
+	config.Informer = informer.Informer()
+
+	repl := UnstructuredReplicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+	repl.ReplicateReferencedBy(append(referencing, &repl)...)
+
+	return &repl
+}
+
+// ResourceClient returns the dynamic traefik.io Middleware client of the provided namespace
+func (r *UnstructuredReplicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(traefik.Middlewares).Namespace(namespace))
+}
+
+// GroupVersionKind is the kind of traefik.io Middlewares
+func (r *UnstructuredReplicator) GroupVersionKind() schema.GroupVersionKind {
+	return traefik.GroupVersion.WithKind("Middleware")
+}
+
+// References lists the names of the Middlewares in the source namespace referenced by a chain Middleware, or the name
+// of the client certificate Secret of a forwardAuth Middleware, like those of Replicator
+func (r *UnstructuredReplicator) References(kind string, sourceObj interface{}) (names []string) {
+	source := sourceObj.(*unstructured.Unstructured)
+
+	switch kind {
+	case "Middleware":
+		middlewares, _, _ := unstructured.NestedSlice(source.Object, "spec", "chain", "middlewares")
+		for _, middleware := range traefik.MapsOf(middlewares) {
+			name, _ := middleware["name"].(string)
+			namespace, _ := middleware["namespace"].(string)
+			if isLocalReference(name, namespace) {
+				names = append(names, name)
+			}
+		}
+	case "Secret":
+		if secretName, _, _ := unstructured.NestedString(source.Object, "spec", "forwardAuth", "tls", "certSecret"); len(secretName) > 0 {
+			names = append(names, secretName)
+		}
+	}
+
+	return
+}
+
+// Prepare builds the replica of the source Middleware in the provided namespace
+func (r *UnstructuredReplicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.GroupVersionKind(), source)
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *UnstructuredReplicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
@@ -0,0 +1,416 @@
+package traefik
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TokenKind is the kind of a lexical token of a rule
+type TokenKind int
+
+const (
+	TokenEOF TokenKind = iota
+	TokenIdent
+	TokenString
+	TokenLParen
+	TokenRParen
+	TokenComma
+	TokenAnd
+	TokenOr
+	TokenNot
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokenEOF:
+		return "end of rule"
+	case TokenIdent:
+		return "matcher"
+	case TokenString:
+		return "string"
+	case TokenLParen:
+		return "'('"
+	case TokenRParen:
+		return "')'"
+	case TokenComma:
+		return "','"
+	case TokenAnd:
+		return "'&&'"
+	case TokenOr:
+		return "'||'"
+	case TokenNot:
+		return "'!'"
+	}
+
+	return "unknown token"
+}
+
+// Token is a lexical token of a rule. The whitespace preceding the token is kept, so that a parsed rule is printed
+// exactly as it was written.
+type Token struct {
+	Kind    TokenKind
+	Leading string
+	Text    string
+	// Pos is the byte offset of Text in the rule
+	Pos int
+}
+
+func (t Token) String() string {
+	return t.Leading + t.Text
+}
+
+// Tokenize splits a rule into its tokens, the last of which is always a TokenEOF holding any trailing whitespace
+func Tokenize(rule string) ([]Token, error) {
+	var tokens []Token
+
+	pos := 0
+	for {
+		start := pos
+		for pos < len(rule) && isSpace(rule[pos]) {
+			pos++
+		}
+		token := Token{Leading: rule[start:pos], Pos: pos}
+
+		if pos == len(rule) {
+			token.Kind = TokenEOF
+			return append(tokens, token), nil
+		}
+
+		end := pos + 1
+		switch c := rule[pos]; {
+		case c == '(':
+			token.Kind = TokenLParen
+		case c == ')':
+			token.Kind = TokenRParen
+		case c == ',':
+			token.Kind = TokenComma
+		case c == '!':
+			token.Kind = TokenNot
+		case c == '&' || c == '|':
+			if end == len(rule) || rule[end] != c {
+				return nil, errors.Errorf("expected %q at offset %d of rule", string([]byte{c, c}), pos)
+			}
+			end++
+			token.Kind = TokenAnd
+			if c == '|' {
+				token.Kind = TokenOr
+			}
+		case c == '`':
+			closing := strings.IndexByte(rule[end:], '`')
+			if closing < 0 {
+				return nil, errors.Errorf("unterminated string at offset %d of rule", pos)
+			}
+			end += closing + 1
+			token.Kind = TokenString
+		case c == '"':
+			for ; end < len(rule) && rule[end] != '"'; end++ {
+				if rule[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(rule) {
+				return nil, errors.Errorf("unterminated string at offset %d of rule", pos)
+			}
+			end++
+			token.Kind = TokenString
+		case isIdent(c):
+			for end < len(rule) && isIdent(rule[end]) {
+				end++
+			}
+			token.Kind = TokenIdent
+		default:
+			return nil, errors.Errorf("unexpected character %q at offset %d of rule", c, pos)
+		}
+
+		token.Text = rule[pos:end]
+		tokens = append(tokens, token)
+		pos = end
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// Expr is a node of the AST of a rule: a *Matcher, *Not, *Binary or *Group
+type Expr interface {
+	// tokens appends the tokens of the expression in the order they were written
+	tokens(tokens []Token) []Token
+}
+
+// Matcher is a call of a matcher such as Host(`example.com`, `example.org`)
+type Matcher struct {
+	Name   Token
+	LParen Token
+	Args   []*Arg
+	// Commas separate the arguments, so there is one less comma than arguments
+	Commas []Token
+	RParen Token
+}
+
+// Arg is a string argument of a matcher
+type Arg struct {
+	Token Token
+	value string
+}
+
+// Not negates an expression
+type Not struct {
+	Op Token
+	X  Expr
+}
+
+// Binary combines two expressions with && or ||
+type Binary struct {
+	X  Expr
+	Op Token
+	Y  Expr
+}
+
+// Group is an expression in parentheses
+type Group struct {
+	LParen Token
+	X      Expr
+	RParen Token
+}
+
+// Rule is a parsed Traefik v2 rule
+type Rule struct {
+	Expr Expr
+	// EOF holds the whitespace trailing the rule
+	EOF Token
+}
+
+// Value is the unquoted value of the argument
+func (a *Arg) Value() string {
+	return a.value
+}
+
+// SetValue replaces the value of the argument, which keeps its original quotes
+func (a *Arg) SetValue(value string) {
+	a.value = value
+	if strings.HasPrefix(a.Token.Text, "`") && !strings.Contains(value, "`") {
+		a.Token.Text = fmt.Sprintf("`%s`", value)
+		return
+	}
+
+	a.Token.Text = strconv.Quote(value)
+}
+
+func (m *Matcher) tokens(tokens []Token) []Token {
+	tokens = append(tokens, m.Name, m.LParen)
+	for index, arg := range m.Args {
+		if index > 0 {
+			tokens = append(tokens, m.Commas[index-1])
+		}
+		tokens = append(tokens, arg.Token)
+	}
+	return append(tokens, m.RParen)
+}
+
+func (n *Not) tokens(tokens []Token) []Token {
+	return n.X.tokens(append(tokens, n.Op))
+}
+
+func (b *Binary) tokens(tokens []Token) []Token {
+	return b.Y.tokens(append(b.X.tokens(tokens), b.Op))
+}
+
+func (g *Group) tokens(tokens []Token) []Token {
+	return append(g.X.tokens(append(tokens, g.LParen)), g.RParen)
+}
+
+// String prints the rule. Rules that have not been modified are printed exactly as they were parsed.
+func (r *Rule) String() string {
+	var builder strings.Builder
+	for _, token := range append(r.Expr.tokens(nil), r.EOF) {
+		builder.WriteString(token.String())
+	}
+
+	return builder.String()
+}
+
+// Inspect traverses the expression in depth-first order, calling visit for each node. The children of a node are
+// only visited if visit returns true.
+func Inspect(expr Expr, visit func(Expr) bool) {
+	if !visit(expr) {
+		return
+	}
+
+	switch e := expr.(type) {
+	case *Not:
+		Inspect(e.X, visit)
+	case *Binary:
+		Inspect(e.X, visit)
+		Inspect(e.Y, visit)
+	case *Group:
+		Inspect(e.X, visit)
+	}
+}
+
+// ParseRule parses a Traefik v2 rule, such as "Host(`example.com`) && (PathPrefix(`/api`) || !Method(`GET`))",
+// into its AST. && binds tighter than ||.
+func ParseRule(rule string) (*Rule, error) {
+	tokens, err := Tokenize(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ruleParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	eof, err := p.expect(TokenEOF)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rule{Expr: expr, EOF: eof}, nil
+}
+
+// ruleParser is a recursive descent parser over the tokens of a rule
+type ruleParser struct {
+	tokens []Token
+	pos    int
+}
+
+func (p *ruleParser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *ruleParser) next() Token {
+	token := p.tokens[p.pos]
+	if token.Kind != TokenEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *ruleParser) expect(kind TokenKind) (Token, error) {
+	token := p.next()
+	if token.Kind != kind {
+		return token, unexpected(token, kind.String())
+	}
+	return token, nil
+}
+
+func unexpected(token Token, expected string) error {
+	if token.Kind == TokenEOF {
+		return errors.Errorf("unexpected end of rule, expected %s", expected)
+	}
+	if token.Kind == TokenIdent || token.Kind == TokenString {
+		return errors.Errorf("unexpected %s %s at offset %d of rule, expected %s", token.Kind, token.Text, token.Pos, expected)
+	}
+	return errors.Errorf("unexpected %s at offset %d of rule, expected %s", token.Kind, token.Pos, expected)
+}
+
+func (p *ruleParser) parseOr() (Expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().Kind == TokenOr {
+		op := p.next()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{X: x, Op: op, Y: y}
+	}
+
+	return x, nil
+}
+
+func (p *ruleParser) parseAnd() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().Kind == TokenAnd {
+		op := p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{X: x, Op: op, Y: y}
+	}
+
+	return x, nil
+}
+
+func (p *ruleParser) parseUnary() (Expr, error) {
+	token := p.next()
+
+	switch token.Kind {
+	case TokenNot:
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Op: token, X: x}, nil
+	case TokenLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		rparen, err := p.expect(TokenRParen)
+		if err != nil {
+			return nil, err
+		}
+		return &Group{LParen: token, X: x, RParen: rparen}, nil
+	case TokenIdent:
+		return p.parseMatcher(token)
+	}
+
+	return nil, unexpected(token, "matcher, '(' or '!'")
+}
+
+func (p *ruleParser) parseMatcher(name Token) (Expr, error) {
+	lparen, err := p.expect(TokenLParen)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := &Matcher{Name: name, LParen: lparen}
+	if p.peek().Kind == TokenRParen {
+		matcher.RParen = p.next()
+		return matcher, nil
+	}
+
+	for {
+		token, err := p.expect(TokenString)
+		if err != nil {
+			return nil, err
+		}
+
+		value := token.Text[1 : len(token.Text)-1]
+		if token.Text[0] == '"' {
+			if value, err = strconv.Unquote(token.Text); err != nil {
+				return nil, errors.Errorf("invalid string %s at offset %d of rule", token.Text, token.Pos)
+			}
+		}
+		matcher.Args = append(matcher.Args, &Arg{Token: token, value: value})
+
+		separator := p.next()
+		switch separator.Kind {
+		case TokenComma:
+			matcher.Commas = append(matcher.Commas, separator)
+		case TokenRParen:
+			matcher.RParen = separator
+			return matcher, nil
+		default:
+			return nil, unexpected(separator, "',' or ')'")
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package traefik
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ruleCases are valid rules, including the rules of tls_test.go
+var ruleCases = []string{
+	"PathPrefix(`/`)",
+	"Host(`subdomain.example.com`)",
+	"Host(`subdomain.example.com`) || Host(`subdomain.placeholder.com`)",
+	"Host(`subdomain.example.com`) && PathPrefix(`/`)",
+	"(Host(`subdomain.example.com`) && PathPrefix(`/`)) || Host(`subdomain.placeholder.com`)",
+	"Host(`subdomain.example.com`, `other.example.com`)",
+	"(Host(`subdomain.example.com`, `other.example.com`) && PathPrefix(`/`)) || Host(`subdomain.placeholder.com`, `other.placeholder.com`)",
+	"Host(`example.com`) && Path(`/path1`,`/path2`,`/path3`)\n",
+	"HostSNI(`db.example.com`, `cache.example.com`)",
+	"HostSNI(`*`)",
+	"HostRegexp(`{subdomain:[a-z]+}.example.com`)",
+	"HostSNIRegexp(`{db:[a-z]{2,}}.db.example.com`) || HostRegexp(`{host:.+}`)",
+	"Host(\"example.com\") && Headers(`X-Test`, \"a \\\"quoted\\\" (value)\")",
+	"  !( Method(`GET`) ||!Method(`POST`) )\t",
+	"ClientIP()",
+}
+
+func Test_ParseRule(t *testing.T) {
+	rule, err := ParseRule("Host(`a.com`) || Path(`/`) && !Method(`GET`)")
+	assert.NoError(t, err)
+
+	or, ok := rule.Expr.(*Binary)
+	assert.True(t, ok)
+	assert.Equal(t, TokenOr, or.Op.Kind)
+	assert.Equal(t, "Host", or.X.(*Matcher).Name.Text)
+
+	and, ok := or.Y.(*Binary)
+	assert.True(t, ok)
+	assert.Equal(t, TokenAnd, and.Op.Kind)
+	assert.Equal(t, "/", and.X.(*Matcher).Args[0].Value())
+	assert.Equal(t, "GET", and.Y.(*Not).X.(*Matcher).Args[0].Value())
+
+	rule, err = ParseRule("Headers(`X-Test`, \"a \\\"b\\\"\")")
+	assert.NoError(t, err)
+	assert.Equal(t, "a \"b\"", rule.Expr.(*Matcher).Args[1].Value())
+}
+
+func Test_ParseRule_RoundTrip(t *testing.T) {
+	for _, match := range ruleCases {
+		rule, err := ParseRule(match)
+		assert.NoError(t, err, match)
+		assert.Equal(t, match, rule.String())
+	}
+}
+
+func Test_ParseRule_Errors(t *testing.T) {
+	tests := map[string]string{
+		"":                           "unexpected end of rule, expected matcher, '(' or '!'",
+		"Host":                       "unexpected end of rule, expected '('",
+		"Host(`a.com`":               "unexpected end of rule, expected ',' or ')'",
+		"Host(`a.com`) &":            "expected \"&&\" at offset 14 of rule",
+		"Host(`a.com`) Path(`/`)":    "unexpected matcher Path at offset 14 of rule, expected end of rule",
+		"Host(`a.com)":               "unterminated string at offset 5 of rule",
+		"Host(acom)":                 "unexpected matcher acom at offset 5 of rule, expected string",
+		"(Host(`a.com`)":             "unexpected end of rule, expected ')'",
+		"Host(`a.com`,)":             "unexpected ')' at offset 13 of rule, expected string",
+		"Host(`a.com`) && # comment": "unexpected character '#' at offset 17 of rule",
+	}
+
+	for match, expected := range tests {
+		_, err := ParseRule(match)
+		if assert.Error(t, err, match) {
+			assert.Equal(t, expected, err.Error(), match)
+		}
+	}
+}
+
+func Test_Arg_SetValue(t *testing.T) {
+	rule, err := ParseRule("Host(`a.com`, \"b.com\")")
+	assert.NoError(t, err)
+
+	args := rule.Expr.(*Matcher).Args
+	args[0].SetValue("c.com")
+	args[1].SetValue("d.com")
+	assert.Equal(t, "Host(`c.com`, \"d.com\")", rule.String())
+
+	// values containing backticks can not be wrapped in backticks
+	args[0].SetValue("e`.com")
+	assert.Equal(t, "Host(\"e`.com\", \"d.com\")", rule.String())
+}
+
+func FuzzParseRule(f *testing.F) {
+	for _, match := range ruleCases {
+		f.Add(match)
+	}
+
+	f.Fuzz(func(t *testing.T, match string) {
+		rule, err := ParseRule(match)
+		if err != nil {
+			return
+		}
+
+		// parsed rules are printed exactly as they were written
+		if printed := rule.String(); printed != match {
+			t.Fatalf("rule %q printed as %q", match, printed)
+		}
+
+		// rewritten rules remain valid and only change the hosts, even if they are only partially rewritten
+		_ = RewriteHosts(rule, RuleSyntaxV2, strings.ToUpper)
+		_ = RewriteHosts(rule, RuleSyntaxV3, strings.ToUpper)
+		rewritten, err := ParseRule(rule.String())
+		if err != nil {
+			t.Fatalf("rewritten rule %q of %q can not be parsed: %v", rule.String(), match, err)
+		}
+		if len(rewritten.Expr.tokens(nil)) != len(rule.Expr.tokens(nil)) {
+			t.Fatalf("rewritten rule %q of %q has a different structure", rule.String(), match)
+		}
+	})
+}
+
+func FuzzPrepareRouteMatch(f *testing.F) {
+	for _, match := range ruleCases {
+		f.Add(match, "")
+		f.Add(match, "*.other.com")
+	}
+
+	f.Fuzz(func(t *testing.T, match, hostname string) {
+		prepared := PrepareRouteMatch("default", match, hostname)
+
+		// valid rules remain valid, invalid rules are kept as-is
+		if _, err := ParseRule(strings.TrimSuffix(match, "\n")); err != nil {
+			if prepared != strings.TrimSuffix(match, "\n") {
+				t.Fatalf("invalid rule %q prepared as %q", match, prepared)
+			}
+			return
+		}
+		if _, err := ParseRule(prepared); err != nil {
+			t.Fatalf("rule %q prepared as invalid rule %q: %v", match, prepared, err)
+		}
+	})
+}
@@ -3,79 +3,111 @@ package traefik
 import (
 	"strings"
 
+	"github.com/pkg/errors"
+
 	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	log "github.com/sirupsen/logrus"
 )
 
-// PrepareRouteMatch replaces all domains with the namespaced version
-func PrepareRouteMatch(namespace, match string, hostname string) string {
-	if !strings.Contains(match, "Host") {
-		return match
-	}
+// hostMatchers are the matchers whose arguments are hostnames
+var hostMatchers = []string{"Host", "HostHeader", "HostSNI"}
 
-	parenParts := uncutSplit(match, parenthesisDelimiter)
+// hostRegexpMatchers are the matchers whose arguments are patterns of hostnames
+var hostRegexpMatchers = []string{"HostRegexp", "HostSNIRegexp"}
 
-	isHost := false
-	for index, part := range parenParts {
-		if isHost {
-			parenParts[index] = prepareDomainStrings(namespace, part, hostname)
-			isHost = false
-			continue
-		}
-		if strings.Contains(part, "Host") {
-			isHost = true
-		}
-	}
+// Syntaxes of rules. Traefik v3 uses its own syntax by default, which can be switched back to the v2 syntax per route.
+const (
+	RuleSyntaxV2 = "v2"
+	RuleSyntaxV3 = "v3"
+)
 
-	return strings.Join(parenParts, "")
+// PrepareRouteMatch replaces all domains of a rule in the v2 syntax with the namespaced version
+func PrepareRouteMatch(namespace, match string, hostname string) string {
+	return PrepareSyntaxRouteMatch(RuleSyntaxV2, match, hostname, func(host string) string {
+		return common.PrepareTLD(namespace, host)
+	})
 }
 
-func prepareDomainStrings(namespace, domainString, hostname string) string {
-	parts := strings.Split(domainString, "`")
+// PrepareSyntaxRouteMatch replaces all domains of a rule in the provided syntax with the hostname, if set, or else the
+// domain itself, prepared by prepareTLD. Rules that can not be parsed or rewritten are returned as-is.
+func PrepareSyntaxRouteMatch(syntax, match, hostname string, prepareTLD func(host string) string) string {
+	// Helm charts can produce rules that end with a newline, which is not part of the rule
+	match = strings.TrimSuffix(match, "\n")
 
-	isHost := false
-	for index, part := range parts {
-		if isHost {
-			if len(hostname) > 0 {
-				parts[index] = common.PrepareTLD(namespace, hostname)
-			} else {
-				parts[index] = common.PrepareTLD(namespace, part)
-			}
+	rule, err := ParseRule(match)
+	if err != nil {
+		log.WithError(err).Warnf("rule %q can not be parsed, its hosts are not rewritten", match)
+		return match
+	}
 
-			isHost = false
-			continue
+	err = RewriteHosts(rule, syntax, func(host string) string {
+		// HostSNI(`*`) matches all connections and is kept as-is
+		if host == "*" {
+			return host
 		}
-		isHost = true
+
+		if len(hostname) > 0 {
+			return prepareTLD(hostname)
+		}
+		return prepareTLD(host)
+	})
+	if err != nil {
+		log.WithError(err).Warnf("hosts of rule %q can not be rewritten", match)
+		return match
 	}
 
-	return strings.Join(parts, "`")
+	return rule.String()
 }
 
-// uncutSplit splits a given string with the delimiter but keeps all characters intact.
-// Use case is specifically for a multi-character delimiter
-func uncutSplit(str string, delimiter func(rune) bool) (strs []string) {
-	if len(str) == 0 {
-		return make([]string, 0)
+// RewriteHosts replaces the arguments of all host matchers of the rule, leaving the rest of the rule untouched. The
+// patterns of HostRegexp and HostSNIRegexp matchers are rewritten with RewriteHostRegexp, or RewriteHostRegexpV3 for
+// rules in the v3 syntax. The rule is only partially rewritten if an error is returned.
+func RewriteHosts(rule *Rule, syntax string, rewrite func(host string) string) (err error) {
+	rewriteRegexp := RewriteHostRegexp
+	if syntax == RuleSyntaxV3 {
+		rewriteRegexp = RewriteHostRegexpV3
 	}
 
-	// Helm chart's can produce edge case where string ends with newline and messes with the rest of this algorithm. Simply trimming it here fixes it.
-	str = strings.TrimSuffix(str, "\n")
+	Inspect(rule.Expr, func(expr Expr) bool {
+		if err != nil {
+			return false
+		}
 
-	var prev int
-	for index, char := range str {
-		if delimiter(char) {
-			strs = append(strs, str[prev:index])
-			prev = index
+		matcher, ok := expr.(*Matcher)
+		if !ok {
+			return true
 		}
-	}
 
-	if delimiter(rune(str[len(str)-1])) {
-		strs = append(strs, string(str[len(str)-1]))
-	}
+		for _, arg := range matcher.Args {
+			host := arg.Value()
+			switch {
+			case isMatcher(matcher, hostMatchers):
+				host = rewrite(host)
+			case isMatcher(matcher, hostRegexpMatchers):
+				if host, err = rewriteRegexp(host, rewrite); err != nil {
+					err = errors.Wrapf(err, "can not rewrite %s at offset %d of rule", matcher.Name.Text, arg.Token.Pos)
+					return false
+				}
+			}
 
-	return
+			if host != arg.Value() {
+				arg.SetValue(host)
+			}
+		}
+		return false
+	})
+
+	return err
 }
 
-// parenthesisDelimiter is a delimiter function for open and close parenthesis
-func parenthesisDelimiter(r rune) bool {
-	return r == '(' || r == ')'
+// isMatcher checks whether the matcher has one of the names. Like Traefik, the names of matchers are also matched
+// in lower and upper case.
+func isMatcher(matcher *Matcher, names []string) bool {
+	for _, name := range names {
+		if matcher.Name.Text == name || matcher.Name.Text == strings.ToLower(name) || matcher.Name.Text == strings.ToUpper(name) {
+			return true
+		}
+	}
+
+	return false
 }
@@ -3,6 +3,7 @@ package traefik
 import (
 	"testing"
 
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -60,34 +61,70 @@ func Test_PrepareRouteMatch_NewLineSuffix(t *testing.T) {
 	assert.Equal(t, "Host(`default.example.com`) && Path(`/path1`,`/path2`,`/path3`)", newMatch)
 }
 
-func Test_prepareDomainStrings(t *testing.T) {
-	assert.Equal(t, "(`default.example.com`", prepareDomainStrings("default", "(`subdomain.example.com`", ""))
-	assert.Equal(t, "`default.example.com`", prepareDomainStrings("default", "`subdomain.example.com`", ""))
-	assert.Equal(t, "`default.example.com`, `default.placeholder.com`", prepareDomainStrings("default", "`subdomain.example.com`, `subdomain.placeholder.com`", ""))
+func Test_PrepareRouteMatch_HostSNI(t *testing.T) {
+	match := "HostSNI(`db.example.com`, `cache.example.com`)"
+	newMatch := PrepareRouteMatch("default", match, "")
+	assert.Equal(t, "HostSNI(`default.example.com`, `default.example.com`)", newMatch)
 }
 
-func Test_prepareDomainStrings_WithFallback(t *testing.T) {
-	assert.Equal(t, "(`default.other.com`", prepareDomainStrings("default", "(`subdomain.example.com`", "*.other.com"))
-	assert.Equal(t, "`default.other.com`", prepareDomainStrings("default", "`subdomain.example.com`", "*.other.com"))
-	assert.Equal(t, "`default.other.com`, `default.other.com`", prepareDomainStrings("default", "`subdomain.example.com`, `subdomain.placeholder.com`", "*.other.com"))
+func Test_PrepareRouteMatch_HostSNIWildcard(t *testing.T) {
+	match := "HostSNI(`*`)"
+	newMatch := PrepareRouteMatch("default", match, "*.other.com")
+	assert.Equal(t, match, newMatch)
 }
 
-func Test_uncutSplit(t *testing.T) {
-	assert.Equal(t, []string{}, uncutSplit("", parenthesisDelimiter))
+func Test_PrepareRouteMatch_HostHeader(t *testing.T) {
+	match := "HostHeader(`subdomain.example.com`) && !PathPrefix(`/admin`)"
+	newMatch := PrepareRouteMatch("default", match, "")
+	assert.Equal(t, "HostHeader(`default.example.com`) && !PathPrefix(`/admin`)", newMatch)
+}
 
-	assert.Equal(t, []string{"", "("}, uncutSplit("(", parenthesisDelimiter))
-	assert.Equal(t, []string{"", ")"}, uncutSplit(")", parenthesisDelimiter))
+func Test_PrepareRouteMatch_DoubleQuoted(t *testing.T) {
+	match := "Host(\"subdomain.example.com\") && Path(`/a(b)`, \"/c)\")"
+	newMatch := PrepareRouteMatch("default", match, "")
+	assert.Equal(t, "Host(\"default.example.com\") && Path(`/a(b)`, \"/c)\")", newMatch)
+}
+
+func Test_PrepareRouteMatch_Nested(t *testing.T) {
+	match := "((Host(`a.example.com`) || host(`b.example.com`))   &&\n\t!(Query(`q=(x)`) || HOST(`c.example.com`)))"
+	newMatch := PrepareRouteMatch("default", match, "*.other.com")
+	assert.Equal(t, "((Host(`default.other.com`) || host(`default.other.com`))   &&\n\t!(Query(`q=(x)`) || HOST(`default.other.com`)))", newMatch)
+}
+
+func Test_PrepareRouteMatch_HostRegexp(t *testing.T) {
+	match := "HostRegexp(`{subdomain:[a-z]+}.example.com`) && PathPrefix(`/`)"
+	newMatch := PrepareRouteMatch("default", match, "")
+	assert.Equal(t, "HostRegexp(`{subdomain:[a-z]+}.default.example.com`) && PathPrefix(`/`)", newMatch)
+}
 
-	assert.Equal(t, []string{"", "(Host", ")"}, uncutSplit("(Host)", parenthesisDelimiter))
-	assert.Equal(t, []string{"Host", "(`myapp.example.com`", ")"}, uncutSplit("Host(`myapp.example.com`)", parenthesisDelimiter))
-	assert.Equal(t, []string{"Host", "(`myapp.example.com`", ") && PathPrefix", "(`/`", ")"}, uncutSplit("Host(`myapp.example.com`) && PathPrefix(`/`)", parenthesisDelimiter))
-	assert.Equal(t,
-		[]string{"", "(Host", "(`myapp.example.com`", ") && PathPrefix", "(`/`", ")", ") || Host", "(`other.example.com`", ")"},
-		uncutSplit("(Host(`myapp.example.com`) && PathPrefix(`/`)) || Host(`other.example.com`)", parenthesisDelimiter))
+func Test_PrepareRouteMatch_HostRegexp_WithFallback(t *testing.T) {
+	match := "HostRegexp(`{subdomain:[a-z]+}.example.com`, `{tenant}-{env}.example.com`)"
+	newMatch := PrepareRouteMatch("default", match, "*.other.com")
+	assert.Equal(t, "HostRegexp(`{subdomain:[a-z]+}.default.other.com`, `{tenant}-{env}.default.other.com`)", newMatch)
+}
+
+func Test_PrepareRouteMatch_HostSNIRegexp(t *testing.T) {
+	match := "HostSNIRegexp(`{db:[a-z]+}.db.example.com`)"
+	newMatch := PrepareRouteMatch("default", match, "")
+	assert.Equal(t, "HostSNIRegexp(`{db:[a-z]+}.default.db.example.com`)", newMatch)
+}
+
+func Test_PrepareRouteMatch_HostRegexp_Unsafe(t *testing.T) {
+	match := "Host(`subdomain.example.com`) || HostRegexp(`{host:.+}`)"
+	newMatch := PrepareRouteMatch("default", match, "")
+	assert.Equal(t, match, newMatch)
+}
+
+func Test_PrepareRouteMatch_Invalid(t *testing.T) {
+	match := "Host(`subdomain.example.com`"
+	newMatch := PrepareRouteMatch("default", match, "")
+	assert.Equal(t, match, newMatch)
 }
 
-func Test_parenthesisDelimiter(t *testing.T) {
-	assert.True(t, parenthesisDelimiter('('))
-	assert.True(t, parenthesisDelimiter(')'))
-	assert.False(t, parenthesisDelimiter('-'))
+func Test_PrepareSyntaxRouteMatch_V3(t *testing.T) {
+	match := "HostRegexp(`^[a-z]+\\.example\\.com$`) || Host(`subdomain.example.com`)"
+	newMatch := PrepareSyntaxRouteMatch(RuleSyntaxV3, match, "", func(host string) string {
+		return common.PrepareTLD("default", host)
+	})
+	assert.Equal(t, "HostRegexp(`^[a-z]+\\.default\\.example\\.com$`) || Host(`default.example.com`)", newMatch)
 }
@@ -0,0 +1,118 @@
+package traefikservice
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	traefikinformers "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/informers/externalversions/traefik/v1alpha1"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type Replicator struct {
+	*common.TypedReplicator[*v1alpha1.TraefikService]
+}
+
+// NewReplicator creates a new TraefikService replicator. TraefikServices are replicated alongside the replicas of the
+// provided replicators and other TraefikServices that reference them, as well as by their own ReplicateTo or
+// ReplicateToMatching annotations.
+func NewReplicator(ctx context.Context, config common.ReplicatorConfig, informer traefikinformers.TraefikServiceInformer, referencing ...common.Replicator) common.Replicator {
+	config.Kind = "TraefikService"
+	config.Informer = informer.Informer()
+
+	repl := Replicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*v1alpha1.TraefikService](ctx, config, &repl)
+	repl.ReplicateReferencedBy(append(referencing, &repl)...)
+
+	return &repl
+}
+
+// ResourceClient returns the TraefikService client of the provided namespace
+func (r *Replicator) ResourceClient(namespace string) common.Client[*v1alpha1.TraefikService] {
+	return r.TraefikClient.TraefikV1alpha1().TraefikServices(namespace)
+}
+
+// GroupVersionKind is the kind of TraefikServices
+func (r *Replicator) GroupVersionKind() schema.GroupVersionKind {
+	return v1alpha1.SchemeGroupVersion.WithKind("TraefikService")
+}
+
+// Prepare builds the replica of the source TraefikService in the provided namespace
+func (r *Replicator) Prepare(namespace string, source *v1alpha1.TraefikService) *v1alpha1.TraefikService {
+	prepared := &v1alpha1.TraefikService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            source.Name,
+			Namespace:       namespace,
+			Labels:          common.PrepareLabels(source.ObjectMeta),
+			Annotations:     common.PrepareAnnotations(source.ObjectMeta),
+			OwnerReferences: common.PrepareOwnerReferences(source.ObjectMeta),
+		},
+		Spec: prepareSpec(source),
+	}
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// References lists the names of the TraefikServices in the source namespace referenced by the replicas of the source
+func (r *Replicator) References(kind string, sourceObj interface{}) (names []string) {
+	if kind != r.Kind {
+		return nil
+	}
+
+	source := sourceObj.(*v1alpha1.TraefikService)
+	for _, service := range loadBalancers(&source.Spec) {
+		if service.Kind == "TraefikService" && isLocalService(source.Namespace, *service) {
+			names = append(names, service.Name)
+		}
+	}
+
+	return
+}
+
+// prepareSpec clears the namespace of the nested service references that resolve to the source namespace, including
+// those that name it explicitly, so that they resolve to the replicated ExternalName Services and TraefikServices in
+// the target namespace
+func prepareSpec(source *v1alpha1.TraefikService) v1alpha1.TraefikServiceSpec {
+	spec := source.Spec.DeepCopy()
+
+	for _, service := range loadBalancers(spec) {
+		if isLocalService(source.Namespace, *service) {
+			service.Namespace = ""
+		}
+	}
+
+	return *spec
+}
+
+// loadBalancers lists the nested service references of weighted and mirroring TraefikServices
+func loadBalancers(spec *v1alpha1.TraefikServiceSpec) (services []*v1alpha1.LoadBalancerSpec) {
+	if spec.Weighted != nil {
+		for i := range spec.Weighted.Services {
+			services = append(services, &spec.Weighted.Services[i].LoadBalancerSpec)
+		}
+	}
+
+	if spec.Mirroring != nil {
+		services = append(services, &spec.Mirroring.LoadBalancerSpec)
+		for i := range spec.Mirroring.Mirrors {
+			services = append(services, &spec.Mirroring.Mirrors[i].LoadBalancerSpec)
+		}
+	}
+
+	return
+}
+
+// isLocalService checks whether the service reference resolves to the source namespace.
+// References to other providers, such as "whoami@file", are never local.
+func isLocalService(namespace string, service v1alpha1.LoadBalancerSpec) bool {
+	return (len(service.Namespace) == 0 || service.Namespace == namespace) && !strings.Contains(service.Name, "@")
+}
+
+// IsDrifted compares the fields of the target that are set by Prepare
+func (r *Replicator) IsDrifted(prepared *v1alpha1.TraefikService, target *v1alpha1.TraefikService) bool {
+	return common.MetadataDrifted(prepared, target) || !equality.Semantic.DeepEqual(prepared.Spec, target.Spec)
+}
@@ -0,0 +1,164 @@
+package traefikservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik/ingressroute"
+	"github.com/stretchr/testify/assert"
+	traefikfake "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/clientset/versioned/fake"
+	traefikinformers "github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/generated/informers/externalversions"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func loadBalancer(name, kind, namespace string) v1alpha1.LoadBalancerSpec {
+	return v1alpha1.LoadBalancerSpec{Name: name, Kind: kind, Namespace: namespace}
+}
+
+func Test_prepareSpec(t *testing.T) {
+	source := &v1alpha1.TraefikService{
+		ObjectMeta: metav1.ObjectMeta{Name: "wrr", Namespace: "default"},
+		Spec: v1alpha1.TraefikServiceSpec{
+			Weighted: &v1alpha1.WeightedRoundRobin{
+				Services: []v1alpha1.Service{
+					{LoadBalancerSpec: loadBalancer("nginx", "Service", "default")},
+					{LoadBalancerSpec: loadBalancer("mirror", "TraefikService", "")},
+					{LoadBalancerSpec: loadBalancer("shared", "Service", "shared")},
+					{LoadBalancerSpec: loadBalancer("whoami@file", "TraefikService", "")},
+				},
+			},
+		},
+	}
+
+	prepared := prepareSpec(source)
+	assert.Equal(t, []v1alpha1.Service{
+		{LoadBalancerSpec: loadBalancer("nginx", "Service", "")},
+		{LoadBalancerSpec: loadBalancer("mirror", "TraefikService", "")},
+		{LoadBalancerSpec: loadBalancer("shared", "Service", "shared")},
+		{LoadBalancerSpec: loadBalancer("whoami@file", "TraefikService", "")},
+	}, prepared.Weighted.Services)
+
+	// the source is left untouched
+	assert.Equal(t, "default", source.Spec.Weighted.Services[0].Namespace)
+
+	r := &Replicator{TypedReplicator: &common.TypedReplicator[*v1alpha1.TraefikService]{GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "TraefikService"}}}}
+	assert.Equal(t, []string{"mirror"}, r.References("TraefikService", source))
+	assert.Nil(t, r.References("Middleware", source))
+}
+
+func Test_prepareSpec_SourceNamespace(t *testing.T) {
+	source := &v1alpha1.TraefikService{
+		ObjectMeta: metav1.ObjectMeta{Name: "wrr", Namespace: "default"},
+		Spec: v1alpha1.TraefikServiceSpec{
+			Weighted: &v1alpha1.WeightedRoundRobin{
+				Services: []v1alpha1.Service{
+					{LoadBalancerSpec: loadBalancer("wrr-canary", "TraefikService", "default")},
+				},
+			},
+		},
+	}
+
+	// references that name the source namespace explicitly resolve to the replicas in the target namespace as well
+	prepared := prepareSpec(source)
+	assert.Equal(t, []v1alpha1.Service{
+		{LoadBalancerSpec: loadBalancer("wrr-canary", "TraefikService", "")},
+	}, prepared.Weighted.Services)
+}
+
+func Test_prepareSpec_Mirroring(t *testing.T) {
+	source := &v1alpha1.TraefikService{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror", Namespace: "default"},
+		Spec: v1alpha1.TraefikServiceSpec{
+			Mirroring: &v1alpha1.Mirroring{
+				LoadBalancerSpec: loadBalancer("nginx", "", "default"),
+				Mirrors: []v1alpha1.MirrorService{
+					{LoadBalancerSpec: loadBalancer("nginx-canary", "", "default"), Percent: 10},
+				},
+			},
+		},
+	}
+
+	prepared := prepareSpec(source)
+	assert.Equal(t, loadBalancer("nginx", "", ""), prepared.Mirroring.LoadBalancerSpec)
+	assert.Equal(t, []v1alpha1.MirrorService{
+		{LoadBalancerSpec: loadBalancer("nginx-canary", "", ""), Percent: 10},
+	}, prepared.Mirroring.Mirrors)
+}
+
+func Test_Replicator_ReplicatesNestedReferences(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "feature-0"}})
+	traefikClient := traefikfake.NewSimpleClientset(
+		&v1alpha1.IngressRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "nginx",
+				Namespace:   "default",
+				Annotations: map[string]string{common.ReplicateTo: "feature-.*"},
+			},
+			Spec: v1alpha1.IngressRouteSpec{
+				Routes: []v1alpha1.Route{{
+					Kind:     "Rule",
+					Match:    "Host(`nginx.example.com`)",
+					Services: []v1alpha1.Service{{LoadBalancerSpec: loadBalancer("wrr", "TraefikService", "")}},
+				}},
+			},
+		},
+		&v1alpha1.TraefikService{
+			ObjectMeta: metav1.ObjectMeta{Name: "wrr", Namespace: "default"},
+			Spec: v1alpha1.TraefikServiceSpec{
+				Weighted: &v1alpha1.WeightedRoundRobin{
+					Services: []v1alpha1.Service{{LoadBalancerSpec: loadBalancer("mirror", "TraefikService", "")}},
+				},
+			},
+		},
+		&v1alpha1.TraefikService{
+			ObjectMeta: metav1.ObjectMeta{Name: "mirror", Namespace: "default"},
+			Spec: v1alpha1.TraefikServiceSpec{
+				Mirroring: &v1alpha1.Mirroring{LoadBalancerSpec: loadBalancer("nginx", "", "")},
+			},
+		},
+	)
+
+	factory := informers.NewSharedInformerFactory(client, 0)
+	traefikFactory := traefikinformers.NewSharedInformerFactory(traefikClient, 0)
+	config := common.ReplicatorConfig{
+		Client:           client,
+		TraefikClient:    traefikClient,
+		NamespaceWatcher: common.NewNamespaceWatcher(factory.Core().V1().Namespaces()),
+	}
+
+	ingressRoutes := ingressroute.NewReplicator(ctx, config, traefikFactory.Traefik().V1alpha1().IngressRoutes())
+	traefikServices := NewReplicator(ctx, config, traefikFactory.Traefik().V1alpha1().TraefikServices(), ingressRoutes)
+
+	factory.Start(ctx.Done())
+	traefikFactory.Start(ctx.Done())
+	go ingressRoutes.Run(ctx)
+	go traefikServices.Run(ctx)
+
+	// the TraefikService referenced by the replicated TraefikService is replicated as well
+	for _, name := range []string{"wrr", "mirror"} {
+		assert.Eventually(t, func() bool {
+			replica, err := traefikClient.TraefikV1alpha1().TraefikServices("feature-0").Get(ctx, name, metav1.GetOptions{})
+			return err == nil && common.IsManagedBy(replica)
+		}, 5*time.Second, 10*time.Millisecond, name)
+	}
+
+	// both are deleted once the IngressRoute is no longer replicated
+	err := traefikClient.TraefikV1alpha1().IngressRoutes("default").Delete(ctx, "nginx", metav1.DeleteOptions{})
+	assert.NoError(t, err)
+
+	for _, name := range []string{"wrr", "mirror"} {
+		assert.Eventually(t, func() bool {
+			_, err := traefikClient.TraefikV1alpha1().TraefikServices("feature-0").Get(ctx, name, metav1.GetOptions{})
+			return err != nil
+		}, 5*time.Second, 10*time.Millisecond, name)
+	}
+}
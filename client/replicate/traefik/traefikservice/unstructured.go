@@ -0,0 +1,102 @@
+package traefikservice
+
+import (
+	"context"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
+	"github.com/traefik/traefik/v2/pkg/provider/kubernetes/crd/traefik/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+)
+
+// UnstructuredReplicator replicates the TraefikServices of the traefik.io API group with the dynamic client
+type UnstructuredReplicator struct {
+	*common.TypedReplicator[*unstructured.Unstructured]
+}
+
+// NewUnstructuredReplicator creates a new traefik.io TraefikService replicator. TraefikServices are replicated
+// alongside the replicas of the provided replicators and other TraefikServices that reference them, as well as by
+// their own ReplicateTo or ReplicateToMatching annotations.
+func NewUnstructuredReplicator(ctx context.Context, config common.ReplicatorConfig, informer informers.GenericInformer, referencing ...common.Replicator) common.Replicator {
+	config.Kind = "TraefikService"
+	config.Informer = informer.Informer()
+
+	repl := UnstructuredReplicator{}
+	repl.TypedReplicator = common.NewTypedReplicator[*unstructured.Unstructured](ctx, config, &repl)
+	repl.ReplicateReferencedBy(append(referencing, &repl)...)
+
+	return &repl
+}
+
+// ResourceClient returns the dynamic traefik.io TraefikService client of the provided namespace
+func (r *UnstructuredReplicator) ResourceClient(namespace string) common.Client[*unstructured.Unstructured] {
+	return common.NewDynamicClient(r.DynamicClient.Resource(traefik.TraefikServices).Namespace(namespace))
+}
+
+// GroupVersionKind is the kind of traefik.io TraefikServices
+func (r *UnstructuredReplicator) GroupVersionKind() schema.GroupVersionKind {
+	return traefik.GroupVersion.WithKind("TraefikService")
+}
+
+// Prepare builds the replica of the source TraefikService in the provided namespace, with the nested service
+// references to the source namespace rewritten like those of Replicator
+func (r *UnstructuredReplicator) Prepare(namespace string, source *unstructured.Unstructured) *unstructured.Unstructured {
+	prepared := common.PrepareUnstructured(namespace, r.GroupVersionKind(), source)
+
+	for _, service := range unstructuredLoadBalancers(prepared) {
+		if isLocalService(source.GetNamespace(), loadBalancerSpec(service)) {
+			delete(service, "namespace")
+		}
+	}
+
+	common.MustSetReplicatedHash(prepared)
+	return prepared
+}
+
+// References lists the names of the TraefikServices in the source namespace referenced by the replicas of the source
+func (r *UnstructuredReplicator) References(kind string, sourceObj interface{}) (names []string) {
+	if kind != r.Kind {
+		return nil
+	}
+
+	source := sourceObj.(*unstructured.Unstructured)
+	for _, service := range unstructuredLoadBalancers(source) {
+		if spec := loadBalancerSpec(service); spec.Kind == "TraefikService" && isLocalService(source.GetNamespace(), spec) {
+			names = append(names, spec.Name)
+		}
+	}
+
+	return
+}
+
+// unstructuredLoadBalancers lists the nested service references of weighted and mirroring TraefikServices, which are
+// modified in place
+func unstructuredLoadBalancers(obj *unstructured.Unstructured) (services []map[string]interface{}) {
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+
+	if weighted, ok := spec["weighted"].(map[string]interface{}); ok {
+		services = append(services, traefik.MapsOf(weighted["services"])...)
+	}
+
+	if mirroring, ok := spec["mirroring"].(map[string]interface{}); ok {
+		services = append(services, mirroring)
+		services = append(services, traefik.MapsOf(mirroring["mirrors"])...)
+	}
+
+	return
+}
+
+// loadBalancerSpec reads the fields of a nested service reference that decide whether it is local
+func loadBalancerSpec(service map[string]interface{}) (spec v1alpha1.LoadBalancerSpec) {
+	spec.Kind, _ = service["kind"].(string)
+	spec.Name, _ = service["name"].(string)
+	spec.Namespace, _ = service["namespace"].(string)
+	return
+}
+
+// IsDrifted compares all fields of the target except for its status
+func (r *UnstructuredReplicator) IsDrifted(prepared *unstructured.Unstructured, target *unstructured.Unstructured) bool {
+	return common.UnstructuredDrifted(prepared, target)
+}
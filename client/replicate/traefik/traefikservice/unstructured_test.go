@@ -0,0 +1,44 @@
+package traefikservice
+
+import (
+	"testing"
+
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_UnstructuredReplicator_Prepare(t *testing.T) {
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "traefik.io/v1alpha1",
+		"kind":       "TraefikService",
+		"metadata":   map[string]interface{}{"name": "mirror", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"mirroring": map[string]interface{}{
+				"name":      "nginx",
+				"namespace": "default",
+				"mirrors": []interface{}{
+					map[string]interface{}{"name": "wrr", "kind": "TraefikService", "percent": int64(10)},
+					map[string]interface{}{"name": "shared", "namespace": "shared"},
+				},
+			},
+		},
+	}}
+
+	r := &UnstructuredReplicator{TypedReplicator: &common.TypedReplicator[*unstructured.Unstructured]{
+		GenericReplicator: &common.GenericReplicator{ReplicatorConfig: common.ReplicatorConfig{Kind: "TraefikService"}},
+	}}
+	prepared := r.Prepare("feature-a", source)
+
+	mirroring, _, _ := unstructured.NestedMap(prepared.Object, "spec", "mirroring")
+	assert.Equal(t, map[string]interface{}{
+		"name": "nginx",
+		"mirrors": []interface{}{
+			map[string]interface{}{"name": "wrr", "kind": "TraefikService", "percent": int64(10)},
+			map[string]interface{}{"name": "shared", "namespace": "shared"},
+		},
+	}, mirroring)
+
+	assert.Equal(t, []string{"wrr"}, r.References("TraefikService", source))
+	assert.Empty(t, r.References("Middleware", source))
+}
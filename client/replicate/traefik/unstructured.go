@@ -0,0 +1,96 @@
+package traefik
+
+import (
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RouteHostname is the hostname of the TopLevelDomain annotation of the source or the default ingress hostname, which
+// replaces all hosts of the replicas, if set
+func RouteHostname(repl *common.GenericReplicator, source metav1.Object) string {
+	hostname, ok := source.GetAnnotations()[common.TopLevelDomain]
+	if !ok && repl.HasDefaultIngressHostname() {
+		hostname = repl.DefaultIngressHostname
+	}
+
+	return hostname
+}
+
+// RuleSyntax is the syntax of the rule of the route, or the provided syntax if the route does not set one
+func RuleSyntax(route map[string]interface{}, syntax string) string {
+	if routeSyntax, ok := route["syntax"].(string); ok && len(routeSyntax) > 0 {
+		return routeSyntax
+	}
+
+	return syntax
+}
+
+// PrepareUnstructuredRoutes rewrites the hosts of the rules of the routes of an IngressRoute or IngressRouteTCP in
+// place. Rules are rewritten in the syntax set by their route or the provided syntax.
+func PrepareUnstructuredRoutes(obj *unstructured.Unstructured, syntax, hostname string, prepareTLD func(host string) string) {
+	for _, route := range RoutesOf(obj) {
+		if match, ok := route["match"].(string); ok {
+			route["match"] = PrepareSyntaxRouteMatch(RuleSyntax(route, syntax), match, hostname, prepareTLD)
+		}
+	}
+}
+
+// PrepareUnstructuredDomains rewrites the TLS domains of the source. If a hostname is provided, it replaces all domains.
+func PrepareUnstructuredDomains(source *unstructured.Unstructured, hostname string, prepareTLD func(host string) string) (domains []interface{}) {
+	if len(hostname) > 0 {
+		return []interface{}{map[string]interface{}{"main": prepareTLD(hostname)}}
+	}
+
+	tls, _ := TLSOf(source)
+	for _, domain := range MapsOf(tls["domains"]) {
+		newDomain := map[string]interface{}{}
+		if main, ok := domain["main"].(string); ok {
+			newDomain["main"] = prepareTLD(main)
+		}
+
+		var sans []interface{}
+		for _, san := range SliceOf(domain["sans"]) {
+			if san, ok := san.(string); ok {
+				sans = append(sans, prepareTLD(san))
+			}
+		}
+		if len(sans) > 0 {
+			newDomain["sans"] = sans
+		}
+
+		domains = append(domains, newDomain)
+	}
+
+	return
+}
+
+// RoutesOf returns the routes of an IngressRoute or IngressRouteTCP, which are modified in place
+func RoutesOf(obj *unstructured.Unstructured) []map[string]interface{} {
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+	return MapsOf(spec["routes"])
+}
+
+// TLSOf returns the TLS configuration of an IngressRoute or IngressRouteTCP, which is modified in place
+func TLSOf(obj *unstructured.Unstructured) (map[string]interface{}, bool) {
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+	tls, ok := spec["tls"].(map[string]interface{})
+	return tls, ok
+}
+
+// MapsOf returns the objects of a list, skipping other values
+func MapsOf(value interface{}) (maps []map[string]interface{}) {
+	for _, item := range SliceOf(value) {
+		if m, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+
+	return
+}
+
+// SliceOf returns the items of a list, or nil if the value is not a list
+func SliceOf(value interface{}) []interface{} {
+	slice, _ := value.([]interface{})
+	return slice
+}
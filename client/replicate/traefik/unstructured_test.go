@@ -0,0 +1,90 @@
+package traefik
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testUnstructuredRoute(routes []interface{}, tls map[string]interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{"routes": routes}
+	if tls != nil {
+		spec["tls"] = tls
+	}
+
+	return &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+}
+
+func Test_PrepareUnstructuredRoutes(t *testing.T) {
+	tests := []struct {
+		name     string
+		route    map[string]interface{}
+		syntax   string
+		hostname string
+		expected string
+	}{
+		{
+			name:     "configured syntax",
+			route:    map[string]interface{}{"match": "HostRegexp(`^[a-z]+\\.example\\.com$`)"},
+			syntax:   RuleSyntaxV3,
+			expected: "HostRegexp(`^[a-z]+\\.default\\.example\\.com$`)",
+		},
+		{
+			name:     "syntax of the route",
+			route:    map[string]interface{}{"match": "HostRegexp(`{subdomain:[a-z]+}.example.com`)", "syntax": RuleSyntaxV2},
+			syntax:   RuleSyntaxV3,
+			expected: "HostRegexp(`{subdomain:[a-z]+}.default.example.com`)",
+		},
+		{
+			name:     "hostname",
+			route:    map[string]interface{}{"match": "HostSNI(`db.example.com`)"},
+			syntax:   RuleSyntaxV2,
+			hostname: "*.other.com",
+			expected: "HostSNI(`default.other.com`)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := testUnstructuredRoute([]interface{}{tt.route}, nil)
+			PrepareUnstructuredRoutes(obj, tt.syntax, tt.hostname, prepareDefault)
+			assert.Equal(t, tt.expected, RoutesOf(obj)[0]["match"])
+		})
+	}
+}
+
+func Test_PrepareUnstructuredDomains(t *testing.T) {
+	tls := map[string]interface{}{
+		"domains": []interface{}{
+			map[string]interface{}{"main": "nginx.example.com", "sans": []interface{}{"www.example.com", int64(1)}},
+			map[string]interface{}{"main": "api.other.com"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		hostname string
+		expected []interface{}
+	}{
+		{
+			name: "domains",
+			expected: []interface{}{
+				map[string]interface{}{"main": "default.example.com", "sans": []interface{}{"default.example.com"}},
+				map[string]interface{}{"main": "default.other.com"},
+			},
+		},
+		{
+			name:     "hostname",
+			hostname: "*.other.com",
+			expected: []interface{}{map[string]interface{}{"main": "default.other.com"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := testUnstructuredRoute(nil, tls)
+			assert.Equal(t, tt.expected, PrepareUnstructuredDomains(source, tt.hostname, prepareDefault))
+		})
+	}
+}
@@ -1,26 +1,62 @@
 package client
 
 import (
+	"context"
+	"time"
+
 	"github.com/alehechka/kube-external-sync/client/liveness"
-	"github.com/alehechka/kube-external-sync/client/replicate/common"
 	log "github.com/sirupsen/logrus"
 )
 
-// SyncExternals syncs Services/Ingress across Namespaces as ExternalName references
-func SyncExternals(config *SyncConfig) (err error) {
+// SyncExternals syncs Services/Ingress across Namespaces as ExternalName references until the context is canceled
+func SyncExternals(ctx context.Context, config *SyncConfig) (err error) {
 	log.Debugf("Starting with following configuration: %#v", *config)
 
-	controller, err := NewController().Initialize(config)
+	controller, err := NewController().Initialize(ctx, config)
 	if err != nil {
 		return err
 	}
+	defer controller.Shutdown()
+
+	return controller.Run(ctx)
+}
+
+// Run starts the informers, replicators and liveness monitor of an initialized controller and blocks until the
+// context is canceled or the liveness monitor fails, after which the replicators are drained before returning
+func (c *Controller) Run(ctx context.Context) (err error) {
+	// a failing liveness monitor shuts the replicators down the same way a canceled context does
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Informers are started on every replica so that standbys keep their caches warm
+	c.StartInformers(ctx.Done())
 
-	go controller.ServiceReplicator.Run()
-	go controller.IngressReplicator.Run()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		if !c.SyncConfig.LeaderElection {
+			c.RunReplicators(ctx)
+			return
+		}
+
+		if err := c.RunWithLeaderElection(ctx, c.RunReplicators); err != nil {
+			log.WithError(err).Fatal("failed to run leader election")
+		}
+	}()
+
+	err = liveness.Serve(ctx, c.SyncConfig.LivenessPort, c.SyncConfig.ShutdownTimeout, c.Replicators())
+	if err != nil {
+		log.WithError(err).Error("liveness monitor failed, shutting down")
+	}
+	cancel()
 
-	if config.EnableTraefik {
-		go controller.TraefikIngressRouteReplicator.Run()
+	select {
+	case <-stopped:
+		log.Info("all replicators stopped")
+	case <-time.After(c.SyncConfig.ShutdownTimeout):
+		log.Warn("timed out waiting for replicators to stop")
 	}
 
-	return liveness.Serve(config.LivenessPort, []common.Replicator{controller.ServiceReplicator, controller.IngressReplicator, controller.TraefikIngressRouteReplicator})
+	return err
 }
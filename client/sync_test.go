@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type blockingReplicator struct {
+	stopped chan struct{}
+}
+
+func (r *blockingReplicator) Run(ctx context.Context) {
+	<-ctx.Done()
+	close(r.stopped)
+}
+
+func (r *blockingReplicator) Synced() bool { return true }
+
+func (r *blockingReplicator) NamespaceAdded(ns *v1.Namespace) {}
+
+func Test_Run_LivenessFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", ":0")
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	replicator := &blockingReplicator{stopped: make(chan struct{})}
+	controller := &Controller{
+		SyncConfig: &SyncConfig{
+			LivenessPort:    listener.Addr().(*net.TCPAddr).Port,
+			ShutdownTimeout: time.Minute,
+		},
+		InformerFactory:   informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0),
+		ServiceReplicator: replicator,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- controller.Run(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run did not return after the liveness monitor failed")
+	}
+
+	select {
+	case <-replicator.stopped:
+	default:
+		t.Fatal("replicator was not stopped")
+	}
+}
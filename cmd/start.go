@@ -1,11 +1,19 @@
 package cmd
 
 import (
+	"fmt"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/alehechka/kube-external-sync/client"
+	"github.com/alehechka/kube-external-sync/client/replicate/certmanager"
+	"github.com/alehechka/kube-external-sync/client/replicate/common"
+	"github.com/alehechka/kube-external-sync/client/replicate/dynamic"
+	"github.com/alehechka/kube-external-sync/client/replicate/traefik"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -19,9 +27,33 @@ const (
 	kubeconfigFlag             = "kubeconfig"
 	podNamespaceFlag           = "pod-namespace"
 	livenessPortFlag           = "liveness-port"
+	shutdownTimeoutFlag        = "shutdown-timeout"
 	resyncPeriodFlag           = "resync-period"
+	workersFlag                = "workers"
 	defaultIngressHostnameFlag = "default-ingress-hostname"
+	hostTemplateFlag           = "host-template"
+	maxHostLabelLengthFlag     = "max-host-label-length"
 	enableTraefikFlag          = "enable-traefik"
+	traefikRuleSyntaxFlag      = "traefik-rule-syntax"
+	enableGatewayAPIFlag       = "enable-gateway-api"
+	enableGatewayAPIAlphaFlag  = "enable-gateway-api-experimental"
+	enableIstioFlag            = "enable-istio"
+	serviceMeshFlag            = "service-mesh"
+	clusterDomainFlag          = "cluster-domain"
+	certIssuerFlag             = "cert-manager-issuer"
+	certIssuerKindFlag         = "cert-manager-issuer-kind"
+	serverSideApplyFlag        = "server-side-apply"
+	enableTLSSecretsFlag       = "enable-tls-secrets"
+	enableConfigMapsFlag       = "enable-configmaps"
+	dynamicResourcesFlag       = "dynamic-resources-config"
+	orphanIntervalFlag         = "orphan-collection-interval"
+	orphanPolicyFlag           = "orphan-policy"
+	leaderElectFlag            = "leader-elect"
+	leaseNameFlag              = "leader-election-lease-name"
+	leaseNamespaceFlag         = "leader-election-namespace"
+	leaseDurationFlag          = "leader-election-lease-duration"
+	renewDeadlineFlag          = "leader-election-renew-deadline"
+	retryPeriodFlag            = "leader-election-retry-period"
 )
 
 func kubeconfig() *cli.StringFlag {
@@ -56,23 +88,155 @@ var startFlags = []cli.Flag{
 		Usage:   "Specifies the port the listen on for the liveness probe.",
 		Value:   8080,
 	},
+	&cli.DurationFlag{
+		Name:    shutdownTimeoutFlag,
+		EnvVars: []string{"SHUTDOWN_TIMEOUT"},
+		Usage:   "Maximum duration to wait for in-flight replications and the liveness monitor to stop after receiving SIGTERM or SIGINT.",
+		Value:   25 * time.Second,
+	},
 	&cli.StringFlag{
 		Name:    resyncPeriodFlag,
 		EnvVars: []string{"RESYNC_PERIOD"},
 		Usage:   "Resynchronization period for the kubelet watcher",
 		Value:   "30m",
 	},
+	&cli.IntFlag{
+		Name:    workersFlag,
+		EnvVars: []string{"WORKERS"},
+		Usage:   "Number of workers each resource controller uses to process its replication queue.",
+		Value:   common.DefaultWorkers,
+	},
 	&cli.StringFlag{
 		Name:    defaultIngressHostnameFlag,
 		EnvVars: []string{"DEFAULT_INGRESS_HOSTNAME"},
 		Usage:   "Default hostname to use when syncing an Ingress or IngressRoute resource and proper annotation is not provided. If this value is left blank, then the hostname will be extracted from the resource being synced.",
 		Value:   "30m",
 	},
+	&cli.StringFlag{
+		Name:    hostTemplateFlag,
+		EnvVars: []string{"HOST_TEMPLATE"},
+		Usage:   "(optional) Go template that prepares the hosts of replicated resources without a host-template annotation, such as '{{ .Namespace }}--{{ .Host.FirstLabel }}.preview.example.com'. By default, the first label of each host is replaced with the namespace.",
+	},
+	&cli.IntFlag{
+		Name:    maxHostLabelLengthFlag,
+		EnvVars: []string{"MAX_HOST_LABEL_LENGTH"},
+		Usage:   fmt.Sprintf("Maximum length of the labels of prepared hosts. Longer labels are truncated and suffixed with a short hash, must be between %d and %d.", common.MinMaxHostLabelLength, common.DefaultMaxHostLabelLength),
+		Value:   common.DefaultMaxHostLabelLength,
+	},
 	&cli.BoolFlag{
 		Name:    enableTraefikFlag,
-		Usage:   "Enables the controller to replicate Traefik CRDs.",
+		Usage:   "Enables the controller to replicate Traefik CRDs of the traefik.containo.us and traefik.io API groups, whichever are installed.",
 		EnvVars: []string{"ENABLE_TRAEFIK"},
 	},
+	&cli.StringFlag{
+		Name:    traefikRuleSyntaxFlag,
+		Usage:   "Syntax of the rules of traefik.io IngressRoutes whose routes do not set one (v2, v3). Should match the defaultRuleSyntax of Traefik v3. Defaults to v2 if traefik.containo.us is also served, as by Traefik v2.10 and v2.11, and to v3 otherwise.",
+		EnvVars: []string{"TRAEFIK_RULE_SYNTAX"},
+	},
+	&cli.BoolFlag{
+		Name:    enableGatewayAPIFlag,
+		Usage:   "Enables the controller to replicate Gateway API HTTPRoutes.",
+		EnvVars: []string{"ENABLE_GATEWAY_API"},
+	},
+	&cli.BoolFlag{
+		Name:    enableGatewayAPIAlphaFlag,
+		Usage:   "Enables the controller to replicate Gateway API GRPCRoutes and TLSRoutes from the experimental channel. Requires --enable-gateway-api.",
+		EnvVars: []string{"ENABLE_GATEWAY_API_EXPERIMENTAL"},
+	},
+	&cli.BoolFlag{
+		Name:    enableIstioFlag,
+		Usage:   "Enables the controller to replicate Istio VirtualServices and DestinationRules.",
+		EnvVars: []string{"ENABLE_ISTIO"},
+	},
+	&cli.StringFlag{
+		Name:    serviceMeshFlag,
+		Usage:   "Service mesh that selects the ExternalName suffix of replicated Services without an external-name-suffix or service-mesh annotation (none, traefik-mesh). istio is an alias of none, since Istio registers Services under their cluster DNS name.",
+		EnvVars: []string{"SERVICE_MESH"},
+		Value:   common.ServiceMeshNone,
+	},
+	&cli.StringFlag{
+		Name:    clusterDomainFlag,
+		Usage:   "DNS domain of the cluster used in the ExternalNames of replicated Services outside of Traefik Mesh.",
+		EnvVars: []string{"CLUSTER_DOMAIN"},
+		Value:   common.DefaultClusterDomain,
+	},
+	&cli.StringFlag{
+		Name:    certIssuerFlag,
+		Usage:   "(optional) name of the cert-manager issuer of Certificates that are created for the rewritten hosts of replicated Ingresses and IngressRoutes with a top-level-domain annotation or default hostname and no tld-secret-name annotation.",
+		EnvVars: []string{"CERT_MANAGER_ISSUER"},
+	},
+	&cli.StringFlag{
+		Name:    certIssuerKindFlag,
+		Usage:   "Kind of the cert-manager issuer (Issuer, ClusterIssuer). An Issuer must exist in every target namespace.",
+		EnvVars: []string{"CERT_MANAGER_ISSUER_KIND"},
+		Value:   certmanager.ClusterIssuerKind,
+	},
+	&cli.BoolFlag{
+		Name:    enableTLSSecretsFlag,
+		Usage:   "Enables the controller to replicate TLS Secrets alongside the Ingresses and IngressRoutes that reference them.",
+		EnvVars: []string{"ENABLE_TLS_SECRETS"},
+	},
+	&cli.BoolFlag{
+		Name:    enableConfigMapsFlag,
+		Usage:   "Enables the controller to replicate ConfigMaps.",
+		EnvVars: []string{"ENABLE_CONFIGMAPS"},
+	},
+	&cli.StringFlag{
+		Name:    dynamicResourcesFlag,
+		Usage:   "(optional) path to a YAML file listing additional resources, such as CRDs, that are replicated with the dynamic client.",
+		EnvVars: []string{"DYNAMIC_RESOURCES_CONFIG"},
+	},
+	&cli.BoolFlag{
+		Name:    serverSideApplyFlag,
+		Usage:   "Writes replicated resources with server-side apply, so that fields set on them by other controllers are kept.",
+		EnvVars: []string{"SERVER_SIDE_APPLY"},
+	},
+	&cli.DurationFlag{
+		Name:    orphanIntervalFlag,
+		Usage:   "Period between sweeps for replicated resources whose source no longer exists or no longer selects their namespace. A single sweep is run at startup if set to 0.",
+		EnvVars: []string{"ORPHAN_COLLECTION_INTERVAL"},
+		Value:   time.Hour,
+	},
+	&cli.StringFlag{
+		Name:    orphanPolicyFlag,
+		Usage:   "What to do with orphaned replicated resources (delete, report)",
+		EnvVars: []string{"ORPHAN_POLICY"},
+		Value:   string(common.OrphanPolicyDelete),
+	},
+	&cli.BoolFlag{
+		Name:    leaderElectFlag,
+		Usage:   "Enables leader election so that only one of multiple controller replicas replicates resources at a time.",
+		EnvVars: []string{"LEADER_ELECT"},
+	},
+	&cli.StringFlag{
+		Name:    leaseNameFlag,
+		Usage:   "Name of the Lease used for leader election.",
+		EnvVars: []string{"LEADER_ELECTION_LEASE_NAME"},
+		Value:   client.DefaultLeaseName,
+	},
+	&cli.StringFlag{
+		Name:    leaseNamespaceFlag,
+		Usage:   "Namespace of the Lease used for leader election. Defaults to the pod namespace.",
+		EnvVars: []string{"LEADER_ELECTION_NAMESPACE"},
+	},
+	&cli.DurationFlag{
+		Name:    leaseDurationFlag,
+		Usage:   "Duration that standby replicas will wait before attempting to acquire leadership after the leader stops renewing.",
+		EnvVars: []string{"LEADER_ELECTION_LEASE_DURATION"},
+		Value:   15 * time.Second,
+	},
+	&cli.DurationFlag{
+		Name:    renewDeadlineFlag,
+		Usage:   "Duration that the leader will retry refreshing leadership before giving it up.",
+		EnvVars: []string{"LEADER_ELECTION_RENEW_DEADLINE"},
+		Value:   10 * time.Second,
+	},
+	&cli.DurationFlag{
+		Name:    retryPeriodFlag,
+		Usage:   "Duration replicas should wait between attempts to acquire or renew leadership.",
+		EnvVars: []string{"LEADER_ELECTION_RETRY_PERIOD"},
+		Value:   2 * time.Second,
+	},
 	&cli.StringFlag{
 		Name:    podNamespaceFlag,
 		Usage:   "Specifies the namespace that current application pod is running in.",
@@ -93,13 +257,89 @@ func startKubeSecretSync(ctx *cli.Context) (err error) {
 		return err
 	}
 
-	return client.SyncExternals(&client.SyncConfig{
+	orphanPolicy := common.OrphanPolicy(strings.ToLower(strings.TrimSpace(ctx.String(orphanPolicyFlag))))
+	if orphanPolicy != common.OrphanPolicyDelete && orphanPolicy != common.OrphanPolicyReport {
+		return fmt.Errorf("invalid %s: %s", orphanPolicyFlag, ctx.String(orphanPolicyFlag))
+	}
+
+	serviceMesh := strings.ToLower(strings.TrimSpace(ctx.String(serviceMeshFlag)))
+	if _, ok := common.MeshExternalNameSuffix(serviceMesh, ""); !ok {
+		return fmt.Errorf("invalid %s: %s", serviceMeshFlag, ctx.String(serviceMeshFlag))
+	}
+
+	traefikRuleSyntax := strings.ToLower(strings.TrimSpace(ctx.String(traefikRuleSyntaxFlag)))
+	if len(traefikRuleSyntax) > 0 && traefikRuleSyntax != traefik.RuleSyntaxV2 && traefikRuleSyntax != traefik.RuleSyntaxV3 {
+		return fmt.Errorf("invalid %s: %s", traefikRuleSyntaxFlag, ctx.String(traefikRuleSyntaxFlag))
+	}
+
+	if ctx.Bool(enableGatewayAPIAlphaFlag) && !ctx.Bool(enableGatewayAPIFlag) {
+		return fmt.Errorf("%s requires %s", enableGatewayAPIAlphaFlag, enableGatewayAPIFlag)
+	}
+
+	var hostTemplate *template.Template
+	if text := ctx.String(hostTemplateFlag); len(strings.TrimSpace(text)) > 0 {
+		if hostTemplate, err = common.ParseHostTemplate(text); err != nil {
+			return fmt.Errorf("invalid %s: %v", hostTemplateFlag, err)
+		}
+	}
+
+	maxHostLabelLength := ctx.Int(maxHostLabelLengthFlag)
+	if maxHostLabelLength < common.MinMaxHostLabelLength || maxHostLabelLength > common.DefaultMaxHostLabelLength {
+		return fmt.Errorf("invalid %s: %d", maxHostLabelLengthFlag, maxHostLabelLength)
+	}
+
+	issuerKind := strings.TrimSpace(ctx.String(certIssuerKindFlag))
+	if issuerKind != certmanager.IssuerKind && issuerKind != certmanager.ClusterIssuerKind {
+		return fmt.Errorf("invalid %s: %s", certIssuerKindFlag, ctx.String(certIssuerKindFlag))
+	}
+
+	var dynamicResources []dynamic.Resource
+	if path := ctx.String(dynamicResourcesFlag); len(path) > 0 {
+		config, err := dynamic.LoadConfig(path)
+		if err != nil {
+			return err
+		}
+		dynamicResources = config.Resources
+	}
+
+	signalCtx, stop := signal.NotifyContext(ctx.Context, syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	return client.SyncExternals(signalCtx, &client.SyncConfig{
 		PodNamespace: ctx.String(podNamespaceFlag),
 
 		LivenessPort:           ctx.Int(livenessPortFlag),
+		ShutdownTimeout:        ctx.Duration(shutdownTimeoutFlag),
 		ResyncPeriod:           resyncPeriod,
+		Workers:                ctx.Int(workersFlag),
 		DefaultIngressHostname: ctx.String(defaultIngressHostnameFlag),
+		HostTemplate:           hostTemplate,
+		MaxHostLabelLength:     maxHostLabelLength,
 		EnableTraefik:          ctx.Bool(enableTraefikFlag),
+		TraefikRuleSyntax:      traefikRuleSyntax,
+		EnableGatewayAPI:       ctx.Bool(enableGatewayAPIFlag),
+		EnableGatewayAPIAlpha:  ctx.Bool(enableGatewayAPIAlphaFlag),
+		EnableIstio:            ctx.Bool(enableIstioFlag),
+		EnableTLSSecrets:       ctx.Bool(enableTLSSecretsFlag),
+		EnableConfigMaps:       ctx.Bool(enableConfigMapsFlag),
+		DynamicResources:       dynamicResources,
+		ServerSideApply:        ctx.Bool(serverSideApplyFlag),
+		ServiceMesh:            serviceMesh,
+		ClusterDomain:          strings.TrimSpace(ctx.String(clusterDomainFlag)),
+		CertificateIssuer: common.CertificateIssuer{
+			Name: strings.TrimSpace(ctx.String(certIssuerFlag)),
+			Kind: issuerKind,
+		},
+
+		OrphanCollectionInterval: ctx.Duration(orphanIntervalFlag),
+		OrphanPolicy:             orphanPolicy,
+
+		LeaderElection: ctx.Bool(leaderElectFlag),
+		LeaseName:      ctx.String(leaseNameFlag),
+		LeaseNamespace: ctx.String(leaseNamespaceFlag),
+		LeaseDuration:  ctx.Duration(leaseDurationFlag),
+		RenewDeadline:  ctx.Duration(renewDeadlineFlag),
+		RetryPeriod:    ctx.Duration(retryPeriodFlag),
 
 		OutOfCluster: ctx.Bool(outOfClusterFlag),
 		KubeConfig:   ctx.String(kubeconfigFlag),